@@ -0,0 +1,94 @@
+package datavolumeconnector
+
+import (
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatautil"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"sync"
+)
+
+// volumeSet merges measurements that share a label set and event time bucket, keeping the order in which they were
+// first seen.
+type volumeSet struct {
+	order  []volumeKey
+	series map[volumeKey]*dataVolume
+}
+
+type volumeKey struct {
+	attributes [16]byte
+	bucket     pcommon.Timestamp
+}
+
+func newVolumeSet() *volumeSet {
+	return &volumeSet{
+		series: map[volumeKey]*dataVolume{},
+	}
+}
+
+func (s *volumeSet) add(volume dataVolume) {
+	key := volumeKey{attributes: pdatautil.MapHash(volume.attributes), bucket: volume.bucket}
+	if existing, ok := s.series[key]; ok {
+		existing.merge(volume)
+		return
+	}
+	s.series[key] = &volume
+	s.order = append(s.order, key)
+}
+
+func (s *volumeSet) volumes() []dataVolume {
+	volumes := make([]dataVolume, 0, len(s.order))
+	for _, key := range s.order {
+		volumes = append(volumes, *s.series[key])
+	}
+	return volumes
+}
+
+// merge adds the measurements of other to the volume.
+func (d *dataVolume) merge(other dataVolume) {
+	d.count += other.count
+	d.profiles += other.profiles
+	d.bytes += other.bytes
+	d.components.add(other.components)
+	if d.sizes == nil {
+		d.sizes = other.sizes
+	} else if other.sizes != nil {
+		d.sizes.merge(other.sizes)
+	}
+	if d.lags == nil {
+		d.lags = other.lags
+	} else if other.lags != nil {
+		d.lags.merge(other.lags)
+	}
+	d.anomalies.add(other.anomalies)
+	d.exemplars.merge(other.exemplars)
+}
+
+// aggregator accumulates data volume measurements per label set between flushes.
+type aggregator struct {
+	mu  sync.Mutex
+	set *volumeSet
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		set: newVolumeSet(),
+	}
+}
+
+func (a *aggregator) add(volumes []dataVolume) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, volume := range volumes {
+		a.set.add(volume)
+	}
+}
+
+// drain returns the accumulated measurements in the order their label sets were first seen and resets the aggregator.
+func (a *aggregator) drain() []dataVolume {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	volumes := a.set.volumes()
+	a.set = newVolumeSet()
+	return volumes
+}
@@ -0,0 +1,215 @@
+package datavolumeconnector
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pprofile"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const componentAttributeKey = "component"
+
+// The parts the bytes of a measurement are split into when bytes_by_component is enabled.
+const (
+	componentBody = iota
+	componentAttributes
+	componentResource
+	componentScope
+	componentEventsLinks
+	componentRecord
+	componentOverhead
+	numByteComponents
+)
+
+var componentNames = [numByteComponents]string{
+	componentBody:        "body",
+	componentAttributes:  "attributes",
+	componentResource:    "resource",
+	componentScope:       "scope",
+	componentEventsLinks: "events_links",
+	componentRecord:      "record",
+	componentOverhead:    "overhead",
+}
+
+// byteComponents holds the bytes of a measurement per component.
+type byteComponents [numByteComponents]int64
+
+func (b *byteComponents) add(other byteComponents) {
+	for i := range b {
+		b[i] += other[i]
+	}
+}
+
+// withOverhead attributes all bytes of total that are not in any other component to the overhead.
+func (b byteComponents) withOverhead(total int) byteComponents {
+	b[componentOverhead] = 0
+	overhead := int64(total)
+	for _, size := range b {
+		overhead -= size
+	}
+	b[componentOverhead] = overhead
+	return b
+}
+
+// withRecord attributes the bytes of the fields of a single record that are not in any other component to the record.
+func (b byteComponents) withRecord(fieldsSize int) byteComponents {
+	record := int64(fieldsSize)
+	for _, size := range b {
+		record -= size
+	}
+	b[componentRecord] = record
+	return b
+}
+
+// Each component is measured as the size of the fields it consists of. The fields of a record that are not part of
+// any other component, such as its timestamps, IDs, names and values, make up the record component. The tags and
+// length prefixes of the records, scopes and resources and the schema URLs are left to the overhead.
+
+func resourceComponents(resource pcommon.Resource) byteComponents {
+	var components byteComponents
+	components[componentResource] = int64(resourceMessageSize(resource))
+	return components
+}
+
+func scopeComponents(scope pcommon.InstrumentationScope) byteComponents {
+	var components byteComponents
+	components[componentScope] = int64(scopeMessageSize(scope))
+	return components
+}
+
+func resourceLogsComponents(resourceLogs plog.ResourceLogs) byteComponents {
+	components := resourceComponents(resourceLogs.Resource())
+	for i := 0; i < resourceLogs.ScopeLogs().Len(); i++ {
+		components.add(scopeLogsComponents(resourceLogs.ScopeLogs().At(i)))
+	}
+	return components
+}
+
+func scopeLogsComponents(scopeLogs plog.ScopeLogs) byteComponents {
+	components := scopeComponents(scopeLogs.Scope())
+	for i := 0; i < scopeLogs.LogRecords().Len(); i++ {
+		components.add(logRecordComponents(scopeLogs.LogRecords().At(i)))
+	}
+	return components
+}
+
+func logRecordComponents(record plog.LogRecord) byteComponents {
+	var components byteComponents
+	components[componentBody] = int64(messageFieldSize(valueSize(record.Body())) - emptyMessageFieldSize)
+	components[componentAttributes] = int64(attributesSize(record.Attributes()))
+	return components.withRecord(logRecordFieldsSize(record))
+}
+
+func resourceSpansComponents(resourceSpans ptrace.ResourceSpans) byteComponents {
+	components := resourceComponents(resourceSpans.Resource())
+	for i := 0; i < resourceSpans.ScopeSpans().Len(); i++ {
+		components.add(scopeSpansComponents(resourceSpans.ScopeSpans().At(i)))
+	}
+	return components
+}
+
+func scopeSpansComponents(scopeSpans ptrace.ScopeSpans) byteComponents {
+	components := scopeComponents(scopeSpans.Scope())
+	for i := 0; i < scopeSpans.Spans().Len(); i++ {
+		components.add(spanComponents(scopeSpans.Spans().At(i)))
+	}
+	return components
+}
+
+func spanComponents(span ptrace.Span) byteComponents {
+	var components byteComponents
+	components[componentAttributes] = int64(attributesSize(span.Attributes()))
+	components[componentEventsLinks] = int64(eventsLinksSize(span))
+	return components.withRecord(spanFieldsSize(span))
+}
+
+func resourceMetricsComponents(resourceMetrics pmetric.ResourceMetrics) byteComponents {
+	components := resourceComponents(resourceMetrics.Resource())
+	for i := 0; i < resourceMetrics.ScopeMetrics().Len(); i++ {
+		components.add(scopeMetricsComponents(resourceMetrics.ScopeMetrics().At(i)))
+	}
+	return components
+}
+
+func scopeMetricsComponents(scopeMetrics pmetric.ScopeMetrics) byteComponents {
+	components := scopeComponents(scopeMetrics.Scope())
+	for i := 0; i < scopeMetrics.Metrics().Len(); i++ {
+		components.add(metricComponents(scopeMetrics.Metrics().At(i)))
+	}
+	return components
+}
+
+// metricComponents takes the metadata of a metric and the attributes of its data points as its attributes. Its name,
+// description, unit and aggregation fields are part of the record along with the fields of its data points, while the
+// framing of the metric, its data and its data points is overhead, as it is for data points measured on their own.
+func metricComponents(metric pmetric.Metric) byteComponents {
+	var components byteComponents
+	components[componentAttributes] = int64(attributesSize(metric.Metadata()))
+	components[componentRecord] = int64(stringFieldSize(metric.Name()) + stringFieldSize(metric.Description()) + stringFieldSize(metric.Unit()))
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < metric.Gauge().DataPoints().Len(); i++ {
+			dataPoint := metric.Gauge().DataPoints().At(i)
+			components.add(dataPointComponents(dataPoint.Attributes(), numberDataPointFieldsSize(dataPoint)))
+		}
+	case pmetric.MetricTypeSum:
+		components[componentRecord] += int64(varintFieldSize(uint64(int64(metric.Sum().AggregationTemporality()))))
+		if metric.Sum().IsMonotonic() {
+			components[componentRecord] += 2
+		}
+		for i := 0; i < metric.Sum().DataPoints().Len(); i++ {
+			dataPoint := metric.Sum().DataPoints().At(i)
+			components.add(dataPointComponents(dataPoint.Attributes(), numberDataPointFieldsSize(dataPoint)))
+		}
+	case pmetric.MetricTypeHistogram:
+		components[componentRecord] += int64(varintFieldSize(uint64(int64(metric.Histogram().AggregationTemporality()))))
+		for i := 0; i < metric.Histogram().DataPoints().Len(); i++ {
+			dataPoint := metric.Histogram().DataPoints().At(i)
+			components.add(dataPointComponents(dataPoint.Attributes(), histogramDataPointFieldsSize(dataPoint)))
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		components[componentRecord] += int64(varintFieldSize(uint64(int64(metric.ExponentialHistogram().AggregationTemporality()))))
+		for i := 0; i < metric.ExponentialHistogram().DataPoints().Len(); i++ {
+			dataPoint := metric.ExponentialHistogram().DataPoints().At(i)
+			components.add(dataPointComponents(dataPoint.Attributes(), exponentialHistogramDataPointFieldsSize(dataPoint)))
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < metric.Summary().DataPoints().Len(); i++ {
+			dataPoint := metric.Summary().DataPoints().At(i)
+			components.add(dataPointComponents(dataPoint.Attributes(), summaryDataPointFieldsSize(dataPoint)))
+		}
+	}
+	return components
+}
+
+// dataPointComponents splits a data point measured on its own, given the size of its fields.
+func dataPointComponents(attributes pcommon.Map, fieldsSize int) byteComponents {
+	var components byteComponents
+	components[componentAttributes] = int64(attributesSize(attributes))
+	return components.withRecord(fieldsSize)
+}
+
+func resourceProfilesComponents(resourceProfiles pprofile.ResourceProfiles) byteComponents {
+	components := resourceComponents(resourceProfiles.Resource())
+	for i := 0; i < resourceProfiles.ScopeProfiles().Len(); i++ {
+		components.add(scopeProfilesComponents(resourceProfiles.ScopeProfiles().At(i)))
+	}
+	return components
+}
+
+func scopeProfilesComponents(scopeProfiles pprofile.ScopeProfiles) byteComponents {
+	components := scopeComponents(scopeProfiles.Scope())
+	for i := 0; i < scopeProfiles.Profiles().Len(); i++ {
+		components.add(profileComponents(scopeProfiles.Profiles().At(i)))
+	}
+	return components
+}
+
+// profileComponents takes the attribute table of a profile as its attributes. The samples, stacks and string table
+// are part of the record.
+func profileComponents(profile pprofile.Profile) byteComponents {
+	var components byteComponents
+	components[componentAttributes] = int64(profileAttributeTableSize(profile))
+	return components.withRecord(profileFieldsSize(profile))
+}
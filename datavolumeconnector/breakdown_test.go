@@ -0,0 +1,107 @@
+package datavolumeconnector
+
+import (
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"path/filepath"
+	"testing"
+)
+
+// The components only take the bytes of fields they consist of, so none of them, including the overhead that is left,
+// may be negative.
+func assertComponents(t *testing.T, components byteComponents, total int) {
+	sum := int64(0)
+	for component, size := range components {
+		assert.GreaterOrEqual(t, size, int64(0), componentNames[component])
+		sum += size
+	}
+	assert.Equal(t, int64(total), sum)
+}
+
+func TestLogsComponents(t *testing.T) {
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_scope_logs.yaml"))
+	require.NoError(t, err)
+
+	for i := 0; i < testLogs.ResourceLogs().Len(); i++ {
+		resourceLogs := testLogs.ResourceLogs().At(i)
+		isolatedPlog := plog.NewLogs()
+		resourceLogs.CopyTo(isolatedPlog.ResourceLogs().AppendEmpty())
+		total := plogSizer.LogsSize(isolatedPlog)
+		components := resourceLogsComponents(resourceLogs).withOverhead(total)
+		assertComponents(t, components, total)
+		assert.Positive(t, components[componentBody])
+		assert.Positive(t, components[componentAttributes])
+		assert.Positive(t, components[componentResource])
+		assert.Positive(t, components[componentScope])
+
+		for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
+			scopeLogs := resourceLogs.ScopeLogs().At(j)
+			assertComponents(t, scopeLogsComponents(scopeLogs).withOverhead(scopeLogsSize(scopeLogs)), scopeLogsSize(scopeLogs))
+			for k := 0; k < scopeLogs.LogRecords().Len(); k++ {
+				logRecord := scopeLogs.LogRecords().At(k)
+				assertComponents(t, logRecordComponents(logRecord).withOverhead(logRecordSize(logRecord)), logRecordSize(logRecord))
+			}
+		}
+	}
+
+	// the body of a record holding nothing but a string body is the tagged and length-prefixed string, the framing of
+	// the body and the empty trace and span IDs are the record, and only the framing of the record is overhead
+	logRecord := plog.NewLogRecord()
+	logRecord.Body().SetStr("0123456789")
+	components := logRecordComponents(logRecord).withOverhead(logRecordSize(logRecord))
+	assert.Equal(t, int64(12), components[componentBody])
+	assert.Equal(t, int64(6), components[componentRecord])
+	assert.Equal(t, int64(2), components[componentOverhead])
+}
+
+func TestTracesComponents(t *testing.T) {
+	testTraces, err := golden.ReadTraces(filepath.Join("testdata", "traces", "input_traces.yaml"))
+	require.NoError(t, err)
+
+	for i := 0; i < testTraces.ResourceSpans().Len(); i++ {
+		resourceSpans := testTraces.ResourceSpans().At(i)
+		isolatedPtraces := ptrace.NewTraces()
+		resourceSpans.CopyTo(isolatedPtraces.ResourceSpans().AppendEmpty())
+		total := ptraceSizer.TracesSize(isolatedPtraces)
+		components := resourceSpansComponents(resourceSpans).withOverhead(total)
+		assertComponents(t, components, total)
+		assert.Positive(t, components[componentEventsLinks])
+		assert.Positive(t, components[componentRecord])
+
+		for j := 0; j < resourceSpans.ScopeSpans().Len(); j++ {
+			spans := resourceSpans.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				assertComponents(t, spanComponents(spans.At(k)).withOverhead(spanSize(spans.At(k))), spanSize(spans.At(k)))
+			}
+		}
+	}
+}
+
+func TestMetricsComponents(t *testing.T) {
+	testMetrics, err := golden.ReadMetrics(filepath.Join("testdata", "metrics", "input_metrics.yaml"))
+	require.NoError(t, err)
+
+	for i := 0; i < testMetrics.ResourceMetrics().Len(); i++ {
+		resourceMetrics := testMetrics.ResourceMetrics().At(i)
+		isolatedPmetrics := pmetric.NewMetrics()
+		resourceMetrics.CopyTo(isolatedPmetrics.ResourceMetrics().AppendEmpty())
+		total := pmetricSizer.MetricsSize(isolatedPmetrics)
+		components := resourceMetricsComponents(resourceMetrics).withOverhead(total)
+		assertComponents(t, components, total)
+		assert.Positive(t, components[componentAttributes])
+		assert.Positive(t, components[componentRecord])
+
+		for j := 0; j < resourceMetrics.ScopeMetrics().Len(); j++ {
+			scopeMetrics := resourceMetrics.ScopeMetrics().At(j)
+			assertComponents(t, scopeMetricsComponents(scopeMetrics).withOverhead(scopeMetricsSize(scopeMetrics)), scopeMetricsSize(scopeMetrics))
+			for k := 0; k < scopeMetrics.Metrics().Len(); k++ {
+				metric := scopeMetrics.Metrics().At(k)
+				assertComponents(t, metricComponents(metric).withOverhead(metricSize(metric)), metricSize(metric))
+			}
+		}
+	}
+}
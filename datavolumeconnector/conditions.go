@@ -0,0 +1,74 @@
+package datavolumeconnector
+
+import (
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"go.opentelemetry.io/collector/component"
+)
+
+// ConditionsConfig holds the OTTL conditions that decide which records of each signal are measured. A record is
+// measured when any of the conditions of its signal matches. All records are measured if no condition is present.
+type ConditionsConfig struct {
+	// Conditions on log records, evaluated in the ottllog context.
+	Logs []string `mapstructure:"logs"`
+	// Conditions on spans, evaluated in the ottlspan context.
+	Traces []string `mapstructure:"traces"`
+	// Conditions on data points, evaluated in the ottldatapoint context.
+	Metrics []string `mapstructure:"metrics"`
+}
+
+// empty reports whether there are no conditions for any signal.
+func (c ConditionsConfig) empty() bool {
+	return len(c.Logs) == 0 && len(c.Traces) == 0 && len(c.Metrics) == 0
+}
+
+func newLogConditions(conditions []string, errorMode ottl.ErrorMode, set component.TelemetrySettings) (*ottl.ConditionSequence[ottllog.TransformContext], error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	parser, err := ottllog.NewParser(ottlfuncs.StandardConverters[ottllog.TransformContext](), set)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.ParseConditions(conditions)
+	if err != nil {
+		return nil, err
+	}
+	sequence := ottllog.NewConditionSequence(parsed, set, ottllog.WithConditionSequenceErrorMode(errorMode))
+	return &sequence, nil
+}
+
+func newSpanConditions(conditions []string, errorMode ottl.ErrorMode, set component.TelemetrySettings) (*ottl.ConditionSequence[ottlspan.TransformContext], error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	parser, err := ottlspan.NewParser(ottlfuncs.StandardConverters[ottlspan.TransformContext](), set)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.ParseConditions(conditions)
+	if err != nil {
+		return nil, err
+	}
+	sequence := ottlspan.NewConditionSequence(parsed, set, ottlspan.WithConditionSequenceErrorMode(errorMode))
+	return &sequence, nil
+}
+
+func newDataPointConditions(conditions []string, errorMode ottl.ErrorMode, set component.TelemetrySettings) (*ottl.ConditionSequence[ottldatapoint.TransformContext], error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	parser, err := ottldatapoint.NewParser(ottlfuncs.StandardConverters[ottldatapoint.TransformContext](), set)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.ParseConditions(conditions)
+	if err != nil {
+		return nil, err
+	}
+	sequence := ottldatapoint.NewConditionSequence(parsed, set, ottldatapoint.WithConditionSequenceErrorMode(errorMode))
+	return &sequence, nil
+}
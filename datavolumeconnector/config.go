@@ -2,20 +2,318 @@ package datavolumeconnector
 
 import (
 	"fmt"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	temporalityDelta      = "delta"
+	temporalityCumulative = "cumulative"
 )
 
 type Config struct {
 	// Resource attributes that will be extracted from resources and appended to output metrics
 	LabelResourceAttributes []string `mapstructure:"label_resource_attributes"`
+	// Log record, span or data point attributes that will be extracted from each record and appended to output metrics. When present, counts are records and bytes leave out the resource and scope each record belongs to.
+	LabelRecordAttributes []string `mapstructure:"label_record_attributes"`
+	// Patterns of attribute keys that are never copied onto output metrics by the patterns of the label attribute lists. Plain keys in those lists are not affected.
+	ExcludeLabelAttributes []string `mapstructure:"exclude_label_attributes"`
+	// The maximum number of attribute keys the patterns of each label attribute list add to a label set. Keys beyond the maximum are left out in key order and a warning is logged. Unlimited if this is 0.
+	MaxMatchedAttributes int `mapstructure:"max_matched_attributes"`
+	// Label output metrics with the instrumentation scope name as otel.scope.name. When present, items and bytes are measured per scope instead of per resource.
+	LabelScopeName bool `mapstructure:"label_scope_name"`
+	// Label output metrics with the instrumentation scope version as otel.scope.version. When present, items and bytes are measured per scope instead of per resource.
+	LabelScopeVersion bool `mapstructure:"label_scope_version"`
+	// Instrumentation scope attributes that will be extracted from scopes and appended to output metrics. When present, items and bytes are measured per scope instead of per resource.
+	LabelScopeAttributes []string `mapstructure:"label_scope_attributes"`
+	// Label output metrics with the severity number of log records as log.severity_number. When present, bytes are those of the log records alone, without their resource and scope.
+	LabelSeverityNumber bool `mapstructure:"label_severity_number"`
+	// Label output metrics with the severity text of log records as log.severity_text. Like label_severity_number, it leaves the resource and scope out of the bytes.
+	LabelSeverityText bool `mapstructure:"label_severity_text"`
+	// Label output metrics with the kind of spans as span.kind. When present, bytes are those of the spans alone, without their resource and scope.
+	LabelSpanKind bool `mapstructure:"label_span_kind"`
+	// Label output metrics with the status code of spans as span.status_code. Like label_span_kind, it leaves the resource and scope out of the bytes.
+	LabelSpanStatusCode bool `mapstructure:"label_span_status_code"`
+	// Label output metrics with the name of metrics as metric.name. When present, each data point is measured on its own, so with the metrics count_unit a metric counts once under each label set of its data points, and bytes leave out the resource, scope and metric each data point belongs to.
+	LabelMetricName bool `mapstructure:"label_metric_name"`
+	// Label output metrics with the data type of metrics (Gauge, Sum, Histogram, ExponentialHistogram or Summary) as metric.type. Changes counts and bytes the same way label_metric_name does.
+	LabelMetricType bool `mapstructure:"label_metric_type"`
+	// How map and slice label values are emitted, one of json (a JSON string), join (comma-separated, map entries as key=value sorted by key) or drop (leave the label out). Defaults to json.
+	ComplexValues string `mapstructure:"complex_values"`
+	// Labels with fixed values added to all output metrics, such as the direction of the pipeline the connector measures.
+	StaticLabels map[string]string `mapstructure:"static_labels"`
+	// Labels identifying the connector instance, taken from its component ID and the collector's own telemetry resource.
+	IdentityLabels IdentityLabelsConfig `mapstructure:"identity_labels"`
+	// Output labels computed by OTTL value expressions, evaluated per resource or per log record, span or data point. Label resource attributes are the simple case of a resource dimension reading a single attribute.
+	Dimensions []DimensionConfig `mapstructure:"dimensions"`
+	// Defaults, renames and normalization of output labels, applied to the final label set of every measurement. Label values are normalized before series are limited, so values that normalize the same end up in one series.
+	Labels []LabelConfig `mapstructure:"labels"`
 	// The name of the bytes measurement metric name. Required if count_metric_name is not present. Byte measurement will not occur if this is not present.
 	BytesMetricName string `mapstructure:"bytes_metric_name"`
 	// The name of the scope item measurement metric name. Required if bytes_metric_name is not present. Scope item measurement will not occur if this is not present.
 	CountMetricName string `mapstructure:"count_metric_name"`
+	// The name of the metric counting profiles. Only emitted for profiles, whose count_metric_name counts samples.
+	ProfileCountMetricName string `mapstructure:"profile_count_metric_name"`
+	// The unit items are counted in, which depends on the signal: log_records for logs, spans or spans_events_links for traces, metrics, data_points, series or data_points_and_buckets for metrics, and samples for profiles. Defaults to the first unit of the signal.
+	CountUnit string `mapstructure:"count_unit"`
+	// Split the bytes metric into a data point per component (body, attributes, resource, scope, events_links, record and overhead) that add up to the total. Requires bytes_metric_name.
+	BytesByComponent bool `mapstructure:"bytes_by_component"`
+	// The name of the histogram metric of individual log record, span or data point sizes. Records are sized for the histogram only, the count and bytes sums keep their resource or scope granularity.
+	SizeHistogramMetricName string `mapstructure:"size_histogram_metric_name"`
+	// The buckets of the size histogram, explicit (the default) or exponential.
+	SizeHistogram SizeHistogramConfig `mapstructure:"size_histogram"`
+	// The name of the histogram metric of the ingestion lag of individual records in milliseconds, the time from their timestamp until the connector receives them. Spans are timed by their end, log records by the timestamp event_time log_timestamp selects. Records with a zero or future-dated timestamp are left out. Records are sampled for the histogram only and leave the count and bytes sums as they are.
+	LagHistogramMetricName string `mapstructure:"lag_histogram_metric_name"`
+	// The buckets of the lag histogram, explicit (the default) or exponential.
+	LagHistogram SizeHistogramConfig `mapstructure:"lag_histogram"`
+	// The name of the metric counting records with a zero, future-dated or out-of-order timestamp, labeled otel.timestamp.anomaly with zero, future or out_of_order. Checking the timestamps of records does not change how the count and bytes sums are measured.
+	TimestampAnomalyMetricName string `mapstructure:"timestamp_anomaly_metric_name"`
+	// How far the timestamp of a record may be ahead of the time the connector receives it before the record counts as future-dated. Future-dated records are placed into the event time bucket of the time they are received. Defaults to 1m.
+	MaxClockSkew time.Duration `mapstructure:"max_clock_skew"`
+	// How far the timestamp of a record may be behind the newest timestamp of the records before it in the same resource before the record counts as out of order. Defaults to 5m.
+	MaxOutOfOrder time.Duration `mapstructure:"max_out_of_order"`
+	// The maximum number of exemplars attached to each data point of the bytes sum, linking to the largest log records or spans of the label set that carry a trace ID, with their size in bytes as the value. The records are picked while the sums are measured as usual. No exemplars are attached if this is not present.
+	MaxExemplars int `mapstructure:"max_exemplars"`
+	// Additional named metrics, each with its own kind, labels and conditions. They are computed in the same pass over a batch as the metrics above.
+	Metrics []MetricConfig `mapstructure:"metrics"`
+	// The maximum number of label sets emitted per metric. Measurements of new label sets beyond the limit are folded into a single series labeled otel.metric.overflow="true", which keeps the data type, static and identity labels. Unlimited if this is not present.
+	MaxSeries int `mapstructure:"max_series"`
+	// The maximum number of distinct values per label. Measurements of label sets with a new value of a label that reached its limit are folded into the overflow series.
+	MaxLabelValues map[string]int `mapstructure:"max_label_values"`
+	// Where the output labels are put, on the resource (the default) or on data point attributes under a single resource.
+	Output OutputConfig `mapstructure:"output"`
+	// The label naming the signal that was measured, which can be renamed or disabled.
+	DataType DataTypeConfig `mapstructure:"data_type"`
+	// Bucketing of measurements by the time records were produced. The bytes of resources and scopes beyond those of their records are shared out among the buckets of their records, so the totals are the same as without bucketing.
+	EventTime EventTimeConfig `mapstructure:"event_time"`
+	// The interval at which accumulated measurements are emitted, one data point per label set. Measurements are emitted for every incoming batch if this is not present.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// The aggregation temporality of the output sums, either delta or cumulative. Defaults to delta.
+	Temporality string `mapstructure:"temporality"`
+	// How long a series is tracked without receiving new data. A series that expires starts over with a new start timestamp, and its cumulative totals reset. Series never expire if this is not present.
+	SeriesExpiration time.Duration `mapstructure:"series_expiration"`
+	// OTTL conditions per signal that select the records to measure. Only matching records are counted, and their bytes leave out their resource and scope.
+	Conditions ConditionsConfig `mapstructure:"conditions"`
+	// The maximum number of goroutines measuring a single batch. Batches holding many resources are split across them, and the results are merged in the order of the batch, so the output does not depend on it. Batches are measured on the pipeline goroutine if this is not present.
+	Parallelism int `mapstructure:"parallelism"`
+	// The minimum number of log records, spans or data points a batch must hold to be measured in parallel. Defaults to 10000.
+	ParallelismThreshold int `mapstructure:"parallelism_threshold"`
+	// Overrides of the options above for the logs, traces and metrics the connector consumes, so a single connector can serve pipelines of several signals.
+	Signals SignalsConfig `mapstructure:"signals"`
+	// How errors from evaluating conditions are handled, one of propagate, ignore or silent. Defaults to propagate.
+	ErrorMode ottl.ErrorMode `mapstructure:"error_mode"`
 }
 
+// signals are the data type values of the signals the connector consumes.
+var signals = []string{dataTypeLogsAttributeValue, dataTypeTracesAttributeValue, dataTypeMetricsAttributeValue, dataTypeProfilesAttributeValue}
+
 func (c *Config) Validate() error {
-	if c.BytesMetricName == "" && c.CountMetricName == "" {
-		return fmt.Errorf("one of bytes_metric_name and/or count_metric_name must be specified")
+	hasMetrics := c.hasMetrics()
+	for _, signal := range signals {
+		hasMetrics = hasMetrics || c.forSignal(signal).hasMetrics()
+	}
+	if !hasMetrics {
+		return fmt.Errorf("one of bytes_metric_name, count_metric_name, profile_count_metric_name, size_histogram_metric_name, lag_histogram_metric_name, timestamp_anomaly_metric_name and/or metrics must be specified")
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
+	for _, signal := range signals {
+		if c.Signals.overrides(signal) == nil {
+			continue
+		}
+		merged := c.forSignal(signal)
+		if err := merged.validate(); err != nil {
+			return fmt.Errorf("signals %s: %w", signal, err)
+		}
+		if _, err := countUnit(signal, merged.CountUnit); err != nil {
+			return fmt.Errorf("signals %s: %w", signal, err)
+		}
+		if signal == dataTypeProfilesAttributeValue {
+			if err := c.Signals.Profiles.validateProfiles(); err != nil {
+				return fmt.Errorf("signals %s: %w", signal, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the options of the connector for a single signal.
+func (c *Config) validate() error {
+	if c.BytesByComponent && c.BytesMetricName == "" {
+		return fmt.Errorf("bytes_by_component requires bytes_metric_name")
+	}
+	if err := c.SizeHistogram.validate("size_histogram"); err != nil {
+		return err
+	}
+	if err := c.LagHistogram.validate("lag_histogram"); err != nil {
+		return err
+	}
+	if c.MaxClockSkew < 0 {
+		return fmt.Errorf("max_clock_skew must not be negative")
+	}
+	if c.MaxOutOfOrder < 0 {
+		return fmt.Errorf("max_out_of_order must not be negative")
+	}
+	if c.MaxExemplars < 0 {
+		return fmt.Errorf("max_exemplars must not be negative")
+	}
+	switch c.ComplexValues {
+	case "", complexValuesJSON, complexValuesJoin, complexValuesDrop:
+	default:
+		return fmt.Errorf("complex_values must be one of %q, %q or %q, got %q", complexValuesJSON, complexValuesJoin, complexValuesDrop, c.ComplexValues)
+	}
+	if _, ok := c.StaticLabels[""]; ok {
+		return fmt.Errorf("static_labels must not have an empty key")
+	}
+	switch c.Output.Labels {
+	case "", outputLabelsResource, outputLabelsAttributes:
+	default:
+		return fmt.Errorf("output labels must be one of %q or %q, got %q", outputLabelsResource, outputLabelsAttributes, c.Output.Labels)
+	}
+	switch c.Output.Resource {
+	case "":
+	case outputResourceEmpty, outputResourceCollector:
+		if c.Output.Labels != outputLabelsAttributes {
+			return fmt.Errorf("output resource requires output labels %q", outputLabelsAttributes)
+		}
+	default:
+		return fmt.Errorf("output resource must be one of %q or %q, got %q", outputResourceEmpty, outputResourceCollector, c.Output.Resource)
+	}
+	if c.FlushInterval < 0 {
+		return fmt.Errorf("flush_interval must not be negative")
+	}
+	if c.EventTime.Interval < 0 {
+		return fmt.Errorf("event_time interval must not be negative")
+	}
+	if c.EventTime.AllowedLateness < 0 {
+		return fmt.Errorf("event_time allowed_lateness must not be negative")
+	}
+	switch c.EventTime.LogTimestamp {
+	case "", logTimestampTimestamp, logTimestampObserved:
+	default:
+		return fmt.Errorf("event_time log_timestamp must be one of %q or %q, got %q", logTimestampTimestamp, logTimestampObserved, c.EventTime.LogTimestamp)
+	}
+	if c.EventTime.enabled() && c.FlushInterval > 0 {
+		return fmt.Errorf("event_time and flush_interval must not both be set")
+	}
+	switch c.Temporality {
+	case "", temporalityDelta, temporalityCumulative:
+	default:
+		return fmt.Errorf("temporality must be one of %q or %q, got %q", temporalityDelta, temporalityCumulative, c.Temporality)
+	}
+	if c.SeriesExpiration < 0 {
+		return fmt.Errorf("series_expiration must not be negative")
+	}
+	if c.Parallelism < 0 {
+		return fmt.Errorf("parallelism must not be negative")
+	}
+	if c.CountUnit != "" && !validCountUnit(c.CountUnit) {
+		return fmt.Errorf("count_unit %q is not a unit of any signal", c.CountUnit)
+	}
+	if c.ParallelismThreshold < 0 {
+		return fmt.Errorf("parallelism_threshold must not be negative")
+	}
+
+	if err := c.validateView(); err != nil {
+		return err
+	}
+	names := map[string]bool{c.CountMetricName: true, c.ProfileCountMetricName: true, c.BytesMetricName: true, c.SizeHistogramMetricName: true,
+		c.LagHistogramMetricName: true, c.TimestampAnomalyMetricName: true}
+	for _, metric := range c.Metrics {
+		if metric.Name == "" {
+			return fmt.Errorf("metrics must have a name")
+		}
+		if names[metric.Name] {
+			return fmt.Errorf("metric %q is defined more than once", metric.Name)
+		}
+		names[metric.Name] = true
+		switch metric.Kind {
+		case "", metricKindCount, metricKindBytes:
+		default:
+			return fmt.Errorf("metric %q kind must be one of %q or %q, got %q", metric.Name, metricKindCount, metricKindBytes, metric.Kind)
+		}
+		viewConfig := metric.viewConfig()
+		viewConfig.ErrorMode = c.ErrorMode
+		if err := viewConfig.validateView(); err != nil {
+			return fmt.Errorf("metric %q: %w", metric.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateView checks the dimensions, conditions and series limits of the view the options make up.
+func (c *Config) validateView() error {
+	if c.MaxSeries < 0 {
+		return fmt.Errorf("max_series must not be negative")
+	}
+	for label, maxValues := range c.MaxLabelValues {
+		if maxValues <= 0 {
+			return fmt.Errorf("max_label_values of %q must be positive", label)
+		}
+	}
+
+	if _, err := newLabelMappings(c.Labels); err != nil {
+		return err
+	}
+	if c.MaxMatchedAttributes < 0 {
+		return fmt.Errorf("max_matched_attributes must not be negative")
+	}
+	for _, pattern := range c.ExcludeLabelAttributes {
+		if _, err := compileAttributePattern(pattern); err != nil {
+			return fmt.Errorf("invalid exclude_label_attributes pattern %q: %w", pattern, err)
+		}
+	}
+	if _, err := newAttributeMatcher("resource", c.LabelResourceAttributes, c, zap.NewNop()); err != nil {
+		return err
+	}
+	if _, err := newAttributeMatcher("scope", c.LabelScopeAttributes, c, zap.NewNop()); err != nil {
+		return err
+	}
+	if _, err := newAttributeMatcher("record", c.LabelRecordAttributes, c, zap.NewNop()); err != nil {
+		return err
+	}
+
+	set := component.TelemetrySettings{Logger: zap.NewNop()}
+	for _, dimension := range c.Dimensions {
+		if dimension.Name == "" {
+			return fmt.Errorf("dimensions must have a name")
+		}
+		if dimension.Value == "" {
+			return fmt.Errorf("dimension %q must have a value", dimension.Name)
+		}
+		switch dimension.context() {
+		case dimensionContextResource, dimensionContextScope, dimensionContextLog, dimensionContextSpan, dimensionContextDataPoint:
+		default:
+			return fmt.Errorf("dimension %q context must be one of %q, %q, %q, %q or %q, got %q", dimension.Name,
+				dimensionContextResource, dimensionContextScope, dimensionContextLog, dimensionContextSpan, dimensionContextDataPoint, dimension.Context)
+		}
+	}
+	if _, err := newResourceDimensions(c, set); err != nil {
+		return fmt.Errorf("invalid resource dimensions: %w", err)
+	}
+	if _, err := newScopeDimensions(c, set); err != nil {
+		return fmt.Errorf("invalid scope dimensions: %w", err)
+	}
+	if _, err := newLogDimensions(c, set); err != nil {
+		return fmt.Errorf("invalid logs dimensions: %w", err)
+	}
+	if _, err := newSpanDimensions(c, set); err != nil {
+		return fmt.Errorf("invalid traces dimensions: %w", err)
+	}
+	if _, err := newDataPointDimensions(c, set); err != nil {
+		return fmt.Errorf("invalid metrics dimensions: %w", err)
+	}
+	if _, err := newLogConditions(c.Conditions.Logs, c.ErrorMode, set); err != nil {
+		return fmt.Errorf("invalid logs conditions: %w", err)
+	}
+	if _, err := newSpanConditions(c.Conditions.Traces, c.ErrorMode, set); err != nil {
+		return fmt.Errorf("invalid traces conditions: %w", err)
+	}
+	if _, err := newDataPointConditions(c.Conditions.Metrics, c.ErrorMode, set); err != nil {
+		return fmt.Errorf("invalid metrics conditions: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,435 @@
+package datavolumeconnector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     *Config
+		wantErr string
+	}{
+		{
+			name: "valid",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				BytesMetricName: "byte_total",
+				FlushInterval:   time.Minute,
+				Temporality:     temporalityCumulative,
+				Conditions: ConditionsConfig{
+					Logs:    []string{`severity_number >= SEVERITY_NUMBER_WARN`},
+					Traces:  []string{`status.code == STATUS_CODE_ERROR`},
+					Metrics: []string{`resource.attributes["k8s.namespace.name"] == "default"`},
+				},
+				Dimensions: []DimensionConfig{
+					{Name: "namespace", Value: `attributes["k8s.namespace.name"]`, Fallbacks: []string{`attributes["namespace"]`}},
+					{Name: "route", Value: `attributes["http.route"]`, Context: "span"},
+					{Name: "level", Value: `severity_text`, Context: "log"},
+				},
+			},
+		},
+		{
+			name:    "no metric names",
+			cfg:     &Config{},
+			wantErr: "one of bytes_metric_name, count_metric_name, profile_count_metric_name, size_histogram_metric_name, lag_histogram_metric_name, timestamp_anomaly_metric_name and/or metrics must be specified",
+		},
+		{
+			name: "negative flush interval",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				FlushInterval:   -time.Second,
+			},
+			wantErr: "flush_interval must not be negative",
+		},
+		{
+			name: "negative event time lateness",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				EventTime:       EventTimeConfig{Interval: time.Minute, AllowedLateness: -time.Second},
+			},
+			wantErr: "event_time allowed_lateness must not be negative",
+		},
+		{
+			name: "unknown event time log timestamp",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				EventTime:       EventTimeConfig{Interval: time.Minute, LogTimestamp: "received"},
+			},
+			wantErr: `event_time log_timestamp must be one of "timestamp" or "observed_timestamp", got "received"`,
+		},
+		{
+			name: "event time with flush interval",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				EventTime:       EventTimeConfig{Interval: time.Minute},
+				FlushInterval:   time.Minute,
+			},
+			wantErr: "event_time and flush_interval must not both be set",
+		},
+		{
+			name: "negative parallelism",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Parallelism:     -1,
+			},
+			wantErr: "parallelism must not be negative",
+		},
+		{
+			name: "negative parallelism threshold",
+			cfg: &Config{
+				CountMetricName:      "count_total",
+				ParallelismThreshold: -1,
+			},
+			wantErr: "parallelism_threshold must not be negative",
+		},
+		{
+			name: "unknown count unit",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				CountUnit:       "bytes",
+			},
+			wantErr: `count_unit "bytes" is not a unit of any signal`,
+		},
+		{
+			name: "count unit of another signal",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Signals: SignalsConfig{
+					Traces: &SignalConfig{CountUnit: ptr(countUnitDataPoints)},
+				},
+			},
+			wantErr: `signals traces: count_unit of traces must be one of "spans" or "spans_events_links", got "data_points"`,
+		},
+		{
+			name: "unknown temporality",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Temporality:     "sometimes",
+			},
+			wantErr: `temporality must be one of "delta" or "cumulative", got "sometimes"`,
+		},
+		{
+			name: "invalid logs condition",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Conditions: ConditionsConfig{
+					Logs: []string{`attributes["log_level"] ==`},
+				},
+			},
+			wantErr: "invalid logs conditions",
+		},
+		{
+			name: "invalid metrics condition",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Conditions: ConditionsConfig{
+					Metrics: []string{`span.name == "foo"`},
+				},
+			},
+			wantErr: "invalid metrics conditions",
+		},
+		{
+			name: "dimension without name",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Dimensions:      []DimensionConfig{{Value: `attributes["service.name"]`}},
+			},
+			wantErr: "dimensions must have a name",
+		},
+		{
+			name: "dimension with unknown context",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Dimensions:      []DimensionConfig{{Name: "service", Value: `attributes["service.name"]`, Context: "record"}},
+			},
+			wantErr: `dimension "service" context must be one of "resource", "scope", "log", "span" or "datapoint", got "record"`,
+		},
+		{
+			name: "invalid resource dimension",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Dimensions:      []DimensionConfig{{Name: "span", Value: `span.name`}},
+			},
+			wantErr: "invalid resource dimensions",
+		},
+		{
+			name: "metrics only",
+			cfg: &Config{
+				Metrics: []MetricConfig{{Name: "bytes_total", Kind: "bytes"}},
+			},
+		},
+		{
+			name: "metric without name",
+			cfg: &Config{
+				Metrics: []MetricConfig{{Kind: "bytes"}},
+			},
+			wantErr: "metrics must have a name",
+		},
+		{
+			name: "metric defined twice",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Metrics:         []MetricConfig{{Name: "count_total"}},
+			},
+			wantErr: `metric "count_total" is defined more than once`,
+		},
+		{
+			name: "metric with unknown kind",
+			cfg: &Config{
+				Metrics: []MetricConfig{{Name: "volume_total", Kind: "volume"}},
+			},
+			wantErr: `metric "volume_total" kind must be one of "count" or "bytes", got "volume"`,
+		},
+		{
+			name: "metric with invalid condition",
+			cfg: &Config{
+				Metrics: []MetricConfig{{Name: "count_total", Conditions: ConditionsConfig{Traces: []string{`name ==`}}}},
+			},
+			wantErr: `metric "count_total": invalid traces conditions`,
+		},
+		{
+			name: "negative max series",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				MaxSeries:       -1,
+			},
+			wantErr: "max_series must not be negative",
+		},
+		{
+			name: "metric without label values",
+			cfg: &Config{
+				Metrics: []MetricConfig{{Name: "count_total", MaxLabelValues: map[string]int{"k8s.pod.name": 0}}},
+			},
+			wantErr: `metric "count_total": max_label_values of "k8s.pod.name" must be positive`,
+		},
+		{
+			name: "bytes by component without bytes metric",
+			cfg: &Config{
+				CountMetricName:  "count_total",
+				BytesByComponent: true,
+			},
+			wantErr: "bytes_by_component requires bytes_metric_name",
+		},
+		{
+			name: "size histogram with explicit and exponential buckets",
+			cfg: &Config{
+				SizeHistogramMetricName: "record_size",
+				SizeHistogram: SizeHistogramConfig{
+					Explicit:    &ExplicitHistogramConfig{Buckets: []float64{64, 128}},
+					Exponential: &ExponentialHistogramConfig{MaxSize: 20},
+				},
+			},
+			wantErr: "size_histogram must not have both explicit and exponential buckets",
+		},
+		{
+			name: "size histogram with unordered buckets",
+			cfg: &Config{
+				SizeHistogramMetricName: "record_size",
+				SizeHistogram: SizeHistogramConfig{
+					Explicit: &ExplicitHistogramConfig{Buckets: []float64{128, 64}},
+				},
+			},
+			wantErr: "size_histogram explicit buckets must be in increasing order",
+		},
+		{
+			name: "lag histogram with unordered buckets",
+			cfg: &Config{
+				LagHistogramMetricName: "record_lag",
+				LagHistogram: SizeHistogramConfig{
+					Explicit: &ExplicitHistogramConfig{Buckets: []float64{1000, 100}},
+				},
+			},
+			wantErr: "lag_histogram explicit buckets must be in increasing order",
+		},
+		{
+			name: "negative max clock skew",
+			cfg: &Config{
+				TimestampAnomalyMetricName: "timestamp_anomalies_total",
+				MaxClockSkew:               -time.Second,
+			},
+			wantErr: "max_clock_skew must not be negative",
+		},
+		{
+			name: "negative max exemplars",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				MaxExemplars:    -1,
+			},
+			wantErr: "max_exemplars must not be negative",
+		},
+		{
+			name: "signal metrics only",
+			cfg: &Config{
+				Signals: SignalsConfig{Logs: &SignalConfig{CountMetricName: ptr("log_records_total")}},
+			},
+		},
+		{
+			name: "signal with invalid dimension",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Signals: SignalsConfig{Traces: &SignalConfig{
+					Dimensions: []DimensionConfig{{Name: "route", Value: `attributes["http.route"`, Context: dimensionContextSpan}},
+				}},
+			},
+			wantErr: "signals traces: invalid traces dimensions",
+		},
+		{
+			name: "signal metric defined twice",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Signals: SignalsConfig{Metrics: &SignalConfig{
+					Metrics: []MetricConfig{{Name: "count_total"}},
+				}},
+			},
+			wantErr: `signals metrics: metric "count_total" is defined more than once`,
+		},
+		{
+			name: "profiles record dimension",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Signals: SignalsConfig{Profiles: &SignalConfig{
+					Dimensions: []DimensionConfig{{Name: "level", Value: `attributes["level"]`, Context: "log"}},
+				}},
+			},
+			wantErr: `signals profiles: dimension "level": context "log" is not supported for profiles`,
+		},
+		{
+			name: "profiles metric conditions",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Signals: SignalsConfig{Profiles: &SignalConfig{
+					Metrics: []MetricConfig{{Name: "errors_total", Conditions: ConditionsConfig{Logs: []string{`severity_number >= SEVERITY_NUMBER_ERROR`}}}},
+				}},
+			},
+			wantErr: `signals profiles: metric "errors_total": conditions are not supported for profiles`,
+		},
+		{
+			name: "invalid label attribute pattern",
+			cfg: &Config{
+				CountMetricName:       "count_total",
+				LabelRecordAttributes: []string{"/(/"},
+			},
+			wantErr: `invalid record attribute pattern "/(/"`,
+		},
+		{
+			name: "negative max matched attributes",
+			cfg: &Config{
+				CountMetricName:      "count_total",
+				MaxMatchedAttributes: -1,
+			},
+			wantErr: "max_matched_attributes must not be negative",
+		},
+		{
+			name: "unknown complex values mode",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				ComplexValues:   "flatten",
+			},
+			wantErr: `complex_values must be one of "json", "join" or "drop", got "flatten"`,
+		},
+		{
+			name: "static label without key",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				StaticLabels:    map[string]string{"": "received"},
+			},
+			wantErr: "static_labels must not have an empty key",
+		},
+		{
+			name: "unknown output labels",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Output:          OutputConfig{Labels: "scope"},
+			},
+			wantErr: `output labels must be one of "resource" or "attributes", got "scope"`,
+		},
+		{
+			name: "output resource with labels on the resource",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Output:          OutputConfig{Resource: outputResourceCollector},
+			},
+			wantErr: `output resource requires output labels "attributes"`,
+		},
+		{
+			name: "label without key",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Labels:          []LabelConfig{{Default: "unknown"}},
+			},
+			wantErr: "labels must have a key",
+		},
+		{
+			name: "label with invalid extract",
+			cfg: &Config{
+				CountMetricName: "count_total",
+				Labels:          []LabelConfig{{Key: "service.name", Extract: "("}},
+			},
+			wantErr: `label "service.name" extract`,
+		},
+		{
+			name: "metric label with negative truncate",
+			cfg: &Config{
+				Metrics: []MetricConfig{{Name: "count_total", Labels: []LabelConfig{{Key: "service.name", Truncate: -1}}}},
+			},
+			wantErr: `metric "count_total": label "service.name" truncate must not be negative`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.cfg.Validate()
+			if testCase.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, testCase.wantErr)
+		})
+	}
+}
+
+func TestConfigForSignal(t *testing.T) {
+	cfg := &Config{
+		CountMetricName:  "count_total",
+		BytesMetricName:  "byte_total",
+		BytesByComponent: true,
+		LabelScopeName:   true,
+		MaxLabelValues:   map[string]int{"service.name": 10},
+		Signals: SignalsConfig{
+			Logs: &SignalConfig{
+				BytesMetricName:  ptr(""),
+				BytesByComponent: ptr(false),
+				LabelScopeName:   ptr(false),
+				MaxLabelValues:   map[string]int{"k8s.pod.name": 5},
+			},
+			Traces: &SignalConfig{
+				CountMetricName: ptr("spans_total"),
+			},
+		},
+	}
+	require.NoError(t, cfg.Validate())
+
+	// options set to empty or false in a signal block turn the shared ones off
+	logs := cfg.forSignal(dataTypeLogsAttributeValue)
+	assert.Equal(t, "count_total", logs.CountMetricName)
+	assert.Empty(t, logs.BytesMetricName)
+	assert.False(t, logs.BytesByComponent)
+	assert.False(t, logs.LabelScopeName)
+	assert.Equal(t, map[string]int{"k8s.pod.name": 5}, logs.MaxLabelValues)
+
+	// options that are not present keep their shared value
+	traces := cfg.forSignal(dataTypeTracesAttributeValue)
+	assert.Equal(t, "spans_total", traces.CountMetricName)
+	assert.Equal(t, "byte_total", traces.BytesMetricName)
+	assert.True(t, traces.BytesByComponent)
+	assert.True(t, traces.LabelScopeName)
+	assert.Equal(t, map[string]int{"service.name": 10}, traces.MaxLabelValues)
+
+	// a signal block that turns off the bytes metric must also turn off its breakdown
+	cfg.Signals.Logs.BytesByComponent = nil
+	assert.ErrorContains(t, cfg.Validate(), "signals logs: bytes_by_component requires bytes_metric_name")
+}
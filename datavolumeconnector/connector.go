@@ -2,13 +2,21 @@ package datavolumeconnector
 
 import (
 	"context"
+	"fmt"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pprofile"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.uber.org/zap"
+	"sync"
 	"time"
 )
 
@@ -16,191 +24,863 @@ type connectorImp struct {
 	config          Config
 	metricsConsumer consumer.Metrics
 	logger          *zap.Logger
-	component.StartFunc
-	component.ShutdownFunc
+	// countUnit is the unit the items of the consumed signal are counted in
+	countUnit string
+
+	// outputResource is the single output resource when labels are put on data point attributes
+	outputResource pcommon.Resource
+
+	// views holds the view of the top-level options, if they name any metric, followed by one view per metrics entry
+	views []*view
+
+	windowStart pcommon.Timestamp
+	done        chan struct{}
+	wg          sync.WaitGroup
+}
+
+// dataVolume holds the measurements taken for a single output label set.
+type dataVolume struct {
+	attributes pcommon.Map
+	count      int64
+	bytes      int64
+	// profiles is only counted for profiles, whose count is their number of samples
+	profiles int64
+	// sizes is only set when a size histogram is configured
+	sizes sizeHistogram
+	// lags is only set when a lag histogram is configured
+	lags sizeHistogram
+	// anomalies is only counted when a timestamp anomaly metric is configured
+	anomalies timestampAnomalies
+	// exemplars is only filled when exemplars are attached
+	exemplars exemplarSet
+	// components is only filled when bytes are broken down by component
+	components byteComponents
+	// overflow marks the measurement of all label sets beyond the series limits
+	overflow bool
+	// late marks the measurement of records whose event time bucket was already emitted
+	late bool
+	// bucket is the start of the event time bucket of the measurement, only set when bucketing by event time
+	bucket pcommon.Timestamp
+}
+
+// recordSample is what the views sample of a single log record, span, data point or profile.
+type recordSample struct {
+	sizing    *sizing
+	timestamp recordTimestamp
+	// the trace and span IDs and the time of the record, only set for log records and spans
+	traceID pcommon.TraceID
+	spanID  pcommon.SpanID
+	time    pcommon.Timestamp
 }
 
 const (
-	dataTypeAttributeKey          = "data_type"
-	dataTypeLogsAttributeValue    = "logs"
-	dataTypeTracesAttributeValue  = "traces"
-	dataTypeMetricsAttributeValue = "metrics"
+	dataTypeAttributeKey           = "data_type"
+	dataTypeLogsAttributeValue     = "logs"
+	dataTypeTracesAttributeValue   = "traces"
+	dataTypeMetricsAttributeValue  = "metrics"
+	dataTypeProfilesAttributeValue = "profiles"
+	scopeNameAttributeKey          = "otel.scope.name"
+	scopeVersionAttributeKey       = "otel.scope.version"
+	severityNumberAttributeKey     = "log.severity_number"
+	severityTextAttributeKey       = "log.severity_text"
+	spanKindAttributeKey           = "span.kind"
+	spanStatusCodeAttributeKey     = "span.status_code"
+	metricNameAttributeKey         = "metric.name"
+	metricTypeAttributeKey         = "metric.type"
 )
 
-var (
-	plogSizer    = plog.ProtoMarshaler{}
-	ptraceSizer  = ptrace.ProtoMarshaler{}
-	pmetricSizer = pmetric.ProtoMarshaler{}
-)
+func newConnector(set connector.Settings, config component.Config, signal string) (*connectorImp, error) {
+	cfg := config.(*Config).forSignal(signal)
+	unit, err := countUnit(signal, cfg.CountUnit)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &connectorImp{
+		config:         *cfg,
+		logger:         set.Logger,
+		countUnit:      unit,
+		outputResource: pcommon.NewResource(),
+	}
+	if cfg.Output.Resource == outputResourceCollector {
+		set.Resource.CopyTo(c.outputResource)
+	}
 
-func newConnector(logger *zap.Logger, config component.Config) (*connectorImp, error) {
-	cfg := config.(*Config)
+	if cfg.CountMetricName != "" || cfg.ProfileCountMetricName != "" || cfg.BytesMetricName != "" || cfg.SizeHistogramMetricName != "" ||
+		cfg.LagHistogramMetricName != "" || cfg.TimestampAnomalyMetricName != "" {
+		v, err := newView(cfg, cfg, c.errorMode(), set.TelemetrySettings)
+		if err != nil {
+			return nil, err
+		}
+		c.views = append(c.views, v)
+	}
+	for _, metric := range cfg.Metrics {
+		v, err := newView(metric.viewConfig(), cfg, c.errorMode(), set.TelemetrySettings)
+		if err != nil {
+			return nil, fmt.Errorf("metric %q: %w", metric.Name, err)
+		}
+		v.description = metric.Description
+		v.unit = metric.Unit
+		c.views = append(c.views, v)
+	}
+	constantLabels := newConstantLabels(cfg, set)
+	for _, v := range c.views {
+		v.constantLabels = constantLabels
+		if v.limiter != nil {
+			overflowLabels := v.constantLabelSet(signal)
+			v.mapLabels(overflowLabels)
+			v.limiter.setConstantLabels(overflowLabels)
+		}
+	}
+	return c, nil
+}
 
-	return &connectorImp{
-		config: *cfg,
-		logger: logger,
-	}, nil
+func (c *connectorImp) errorMode() ottl.ErrorMode {
+	if c.config.ErrorMode == "" {
+		return ottl.PropagateError
+	}
+	return c.config.ErrorMode
 }
 
 func (c *connectorImp) Capabilities() consumer.Capabilities {
 	return consumer.Capabilities{MutatesData: false}
 }
 
+func (c *connectorImp) Start(_ context.Context, _ component.Host) error {
+	interval := c.config.FlushInterval
+	if c.config.EventTime.enabled() {
+		interval = c.config.EventTime.Interval
+	}
+	if interval <= 0 {
+		return nil
+	}
+
+	c.windowStart = pcommon.NewTimestampFromTime(time.Now())
+	c.done = make(chan struct{})
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.flush(context.Background(), false); err != nil {
+					c.logger.Error("error flushing datavolume metrics", zap.Error(err))
+				}
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *connectorImp) Shutdown(ctx context.Context) error {
+	if c.config.FlushInterval <= 0 && !c.config.EventTime.enabled() {
+		return nil
+	}
+
+	if c.done != nil {
+		close(c.done)
+		c.wg.Wait()
+		c.done = nil
+	}
+	return c.flush(ctx, true)
+}
+
 func (c *connectorImp) ConsumeLogs(ctx context.Context, logs plog.Logs) error {
-	outputMetrics := pmetric.NewMetrics()
-	timestamp := pcommon.NewTimestampFromTime(time.Now())
+	resourceLogs := logs.ResourceLogs()
+	batch, err := c.measure(resourceLogs.Len(), logs.LogRecordCount(), func(batch *batch, i int) error {
+		return c.measureResourceLogs(ctx, batch, resourceLogs.At(i))
+	})
+	if err != nil {
+		return err
+	}
+	return c.export(ctx, batch)
+}
 
-	for i := 0; i < logs.ResourceLogs().Len(); i++ {
-		resourceLogs := logs.ResourceLogs().At(i)
+// measureResourceLogs measures a single resource of a batch for every view.
+func (c *connectorImp) measureResourceLogs(ctx context.Context, batch *batch, resourceLogs plog.ResourceLogs) error {
+	resourceLabels := make([]pcommon.Map, len(c.views))
+	scopeLabels := make([]pcommon.Map, len(c.views))
+	timestamps := c.newTimestampChecker(batch)
 
-		resourceAttributes := resourceLogs.Resource().Attributes()
-		rawAttributes := resourceAttributes.AsRaw()
+	for v, view := range c.views {
+		var err error
+		if resourceLabels[v], err = view.resourceLabels(ctx, resourceLogs.Resource(), resourceLogs, dataTypeLogsAttributeValue); err != nil {
+			return err
+		}
+	}
+
+	spreads := c.spreadResourceLogs(batch, resourceLogs)
+	resourceSizing := newSizing(func() int { return resourceLogsSize(resourceLogs) }, func() byteComponents { return resourceLogsComponents(resourceLogs) })
+	for v, view := range c.views {
+		if view.measureScopes() || view.measureRecords() {
+			continue
+		}
+		view.mapLabels(resourceLabels[v])
+		volume := dataVolume{
+			attributes: resourceLabels[v],
+		}
+		for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
+			volume.count += int64(resourceLogs.ScopeLogs().At(j).LogRecords().Len())
+		}
+		view.measure(&volume, resourceSizing)
+		batch.addSpread(v, view, volume, spreads.all())
+	}
 
-		metricAttrMap := map[string]any{}
+	if !c.measureScopes() {
+		return nil
+	}
+	for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
+		scopeLogs := resourceLogs.ScopeLogs().At(j)
+		scopeSizing := newSizing(func() int { return scopeLogsSize(scopeLogs) }, func() byteComponents { return scopeLogsComponents(scopeLogs) })
+		for v, view := range c.views {
+			if !view.measureScopes() && !view.measureRecords() {
+				scopeLabels[v] = resourceLabels[v]
+				continue
+			}
+			var err error
+			if scopeLabels[v], err = view.scopeLabels(ctx, resourceLabels[v], scopeLogs.Scope(), resourceLogs.Resource(), scopeLogs); err != nil {
+				return err
+			}
+			if !view.measureRecords() {
+				view.mapLabels(scopeLabels[v])
+				volume := dataVolume{
+					attributes: scopeLabels[v],
+					count:      int64(scopeLogs.LogRecords().Len()),
+				}
+				view.measure(&volume, scopeSizing)
+				batch.addSpread(v, view, volume, spreads.scope(j))
+			}
+		}
 
-		metricAttrMap[dataTypeAttributeKey] = dataTypeLogsAttributeValue
-		for _, key := range c.config.LabelResourceAttributes {
-			if rawAttributes[key] != nil {
-				metricAttrMap[key] = rawAttributes[key]
+		if !c.measureRecords() {
+			continue
+		}
+		samples := c.newSamples(scopeLabels)
+		logRecords := scopeLogs.LogRecords()
+		for k := 0; k < logRecords.Len(); k++ {
+			logRecord := logRecords.At(k)
+			tCtx := ottllog.NewTransformContext(logRecord, scopeLogs.Scope(), resourceLogs.Resource(), scopeLogs, resourceLogs)
+			recordSizing := newSizing(func() int { return logRecordSize(logRecord) }, func() byteComponents { return logRecordComponents(logRecord) })
+			logTime := c.config.EventTime.logTime(logRecord)
+			bucket := c.bucket(batch, logTime)
+			record := &recordSample{
+				sizing:    recordSizing,
+				timestamp: timestamps.check(logTime),
+				traceID:   logRecord.TraceID(),
+				spanID:    logRecord.SpanID(),
+				time:      batch.recordTime(logTime),
+			}
+			for v, view := range c.views {
+				batch.sample(v, view, samples, record, bucket)
+				if !view.measureRecords() {
+					continue
+				}
+				if view.logConditions != nil {
+					match, err := view.logConditions.Eval(ctx, tCtx)
+					if err != nil {
+						return err
+					}
+					if !match {
+						continue
+					}
+				}
+				volume := dataVolume{
+					attributes: view.recordLabels(scopeLabels[v], logRecord.Attributes()),
+					count:      1,
+					bucket:     bucket,
+				}
+				if err := putDimensions(ctx, view.logDimensions, tCtx, volume.attributes, view.errorMode, view.logger); err != nil {
+					return err
+				}
+				view.mapLabels(volume.attributes)
+				view.measure(&volume, recordSizing)
+				view.sample(&volume, record)
+				batch.add(v, volume)
 			}
 		}
+		batch.addSamples(samples)
+	}
+	return nil
+}
 
-		outputResourceMetrics := outputMetrics.ResourceMetrics().AppendEmpty()
-		err := outputResourceMetrics.Resource().Attributes().FromRaw(metricAttrMap)
-		if err != nil {
-			c.logger.Error("error adding attributes to datavolume metric for logs measurement", zap.Error(err), zap.Any("attributes_map", metricAttrMap))
+func (c *connectorImp) ConsumeTraces(ctx context.Context, traces ptrace.Traces) error {
+	resourceSpans := traces.ResourceSpans()
+	batch, err := c.measure(resourceSpans.Len(), traces.SpanCount(), func(batch *batch, i int) error {
+		return c.measureResourceSpans(ctx, batch, resourceSpans.At(i))
+	})
+	if err != nil {
+		return err
+	}
+	return c.export(ctx, batch)
+}
+
+// measureResourceSpans measures a single resource of a batch for every view.
+func (c *connectorImp) measureResourceSpans(ctx context.Context, batch *batch, resourceSpans ptrace.ResourceSpans) error {
+	resourceLabels := make([]pcommon.Map, len(c.views))
+	scopeLabels := make([]pcommon.Map, len(c.views))
+	timestamps := c.newTimestampChecker(batch)
+
+	for v, view := range c.views {
+		var err error
+		if resourceLabels[v], err = view.resourceLabels(ctx, resourceSpans.Resource(), resourceSpans, dataTypeTracesAttributeValue); err != nil {
+			return err
 		}
-		outputScopeMetric := outputResourceMetrics.ScopeMetrics().AppendEmpty()
+	}
 
-		if c.config.CountMetricName != "" {
-			countValue := 0
-			for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
-				scopeLogs := resourceLogs.ScopeLogs().At(j)
-				countValue += scopeLogs.LogRecords().Len()
+	spreads := c.spreadResourceSpans(batch, resourceSpans)
+	resourceSizing := newSizing(func() int { return resourceSpansSize(resourceSpans) }, func() byteComponents { return resourceSpansComponents(resourceSpans) })
+	for v, view := range c.views {
+		if view.measureScopes() || view.measureRecords() {
+			continue
+		}
+		view.mapLabels(resourceLabels[v])
+		volume := dataVolume{
+			attributes: resourceLabels[v],
+		}
+		for j := 0; j < resourceSpans.ScopeSpans().Len(); j++ {
+			volume.count += scopeSpansCount(resourceSpans.ScopeSpans().At(j), c.countUnit)
+		}
+		view.measure(&volume, resourceSizing)
+		batch.addSpread(v, view, volume, spreads.all())
+	}
+
+	if !c.measureScopes() {
+		return nil
+	}
+	for j := 0; j < resourceSpans.ScopeSpans().Len(); j++ {
+		scopeSpans := resourceSpans.ScopeSpans().At(j)
+		scopeSizing := newSizing(func() int { return scopeSpansSize(scopeSpans) }, func() byteComponents { return scopeSpansComponents(scopeSpans) })
+		for v, view := range c.views {
+			if !view.measureScopes() && !view.measureRecords() {
+				scopeLabels[v] = resourceLabels[v]
+				continue
+			}
+			var err error
+			if scopeLabels[v], err = view.scopeLabels(ctx, resourceLabels[v], scopeSpans.Scope(), resourceSpans.Resource(), scopeSpans); err != nil {
+				return err
+			}
+			if !view.measureRecords() {
+				view.mapLabels(scopeLabels[v])
+				volume := dataVolume{
+					attributes: scopeLabels[v],
+					count:      scopeSpansCount(scopeSpans, c.countUnit),
+				}
+				view.measure(&volume, scopeSizing)
+				batch.addSpread(v, view, volume, spreads.scope(j))
 			}
-			addOutputMetricToScopeMetrics(outputScopeMetric, c.config.CountMetricName, "", timestamp, int64(countValue))
 		}
 
-		if c.config.BytesMetricName != "" {
-			isolatedPlog := plog.NewLogs()
-			isolatedResourceLogs := isolatedPlog.ResourceLogs().AppendEmpty()
-			// TODO: Opportunity for optimization here. Can we use protoreflect to measure these instead? Or add a reference instead?
-			resourceLogs.CopyTo(isolatedResourceLogs)
-			bytes := int64(plogSizer.LogsSize(isolatedPlog))
-			addOutputMetricToScopeMetrics(outputScopeMetric, c.config.BytesMetricName, "bytes", timestamp, bytes)
+		if !c.measureRecords() {
+			continue
+		}
+		samples := c.newSamples(scopeLabels)
+		spans := scopeSpans.Spans()
+		for k := 0; k < spans.Len(); k++ {
+			span := spans.At(k)
+			tCtx := ottlspan.NewTransformContext(span, scopeSpans.Scope(), resourceSpans.Resource(), scopeSpans, resourceSpans)
+			recordSizing := newSizing(func() int { return spanSize(span) }, func() byteComponents { return spanComponents(span) })
+			bucket := c.bucket(batch, span.StartTimestamp())
+			record := &recordSample{
+				sizing:    recordSizing,
+				timestamp: timestamps.check(span.EndTimestamp()),
+				traceID:   span.TraceID(),
+				spanID:    span.SpanID(),
+				time:      batch.recordTime(span.EndTimestamp()),
+			}
+			for v, view := range c.views {
+				batch.sample(v, view, samples, record, bucket)
+				if !view.measureRecords() {
+					continue
+				}
+				if view.spanConditions != nil {
+					match, err := view.spanConditions.Eval(ctx, tCtx)
+					if err != nil {
+						return err
+					}
+					if !match {
+						continue
+					}
+				}
+				volume := dataVolume{
+					attributes: view.recordLabels(scopeLabels[v], span.Attributes()),
+					count:      spanCount(span, c.countUnit),
+					bucket:     bucket,
+				}
+				if err := putDimensions(ctx, view.spanDimensions, tCtx, volume.attributes, view.errorMode, view.logger); err != nil {
+					return err
+				}
+				view.mapLabels(volume.attributes)
+				view.measure(&volume, recordSizing)
+				view.sample(&volume, record)
+				batch.add(v, volume)
+			}
 		}
+		batch.addSamples(samples)
 	}
+	return nil
+}
 
-	return c.metricsConsumer.ConsumeMetrics(ctx, outputMetrics)
+func (c *connectorImp) ConsumeMetrics(ctx context.Context, metrics pmetric.Metrics) error {
+	resourceMetrics := metrics.ResourceMetrics()
+	batch, err := c.measure(resourceMetrics.Len(), metrics.DataPointCount(), func(batch *batch, i int) error {
+		return c.measureResourceMetrics(ctx, batch, resourceMetrics.At(i))
+	})
+	if err != nil {
+		return err
+	}
+	return c.export(ctx, batch)
 }
 
-func (c *connectorImp) ConsumeTraces(ctx context.Context, traces ptrace.Traces) error {
-	outputMetrics := pmetric.NewMetrics()
-	timestamp := pcommon.NewTimestampFromTime(time.Now())
+// measureResourceMetrics measures a single resource of a batch for every view.
+func (c *connectorImp) measureResourceMetrics(ctx context.Context, batch *batch, resourceMetrics pmetric.ResourceMetrics) error {
+	resourceLabels := make([]pcommon.Map, len(c.views))
+	scopeLabels := make([]pcommon.Map, len(c.views))
+	timestamps := c.newTimestampChecker(batch)
+
+	for v, view := range c.views {
+		var err error
+		if resourceLabels[v], err = view.resourceLabels(ctx, resourceMetrics.Resource(), resourceMetrics, dataTypeMetricsAttributeValue); err != nil {
+			return err
+		}
+	}
+
+	spreads := c.spreadResourceMetrics(batch, resourceMetrics)
+	resourceSizing := newSizing(func() int { return resourceMetricsSize(resourceMetrics) }, func() byteComponents { return resourceMetricsComponents(resourceMetrics) })
+	for v, view := range c.views {
+		if view.measureScopes() || view.measureRecords() {
+			continue
+		}
+		view.mapLabels(resourceLabels[v])
+		volume := dataVolume{
+			attributes: resourceLabels[v],
+		}
+		for j := 0; j < resourceMetrics.ScopeMetrics().Len(); j++ {
+			volume.count += scopeMetricsCount(resourceMetrics.ScopeMetrics().At(j), c.countUnit)
+		}
+		view.measure(&volume, resourceSizing)
+		batch.addSpread(v, view, volume, spreads.all())
+	}
+
+	if !c.measureScopes() {
+		return nil
+	}
+	for j := 0; j < resourceMetrics.ScopeMetrics().Len(); j++ {
+		scopeMetrics := resourceMetrics.ScopeMetrics().At(j)
+		scopeSizing := newSizing(func() int { return scopeMetricsSize(scopeMetrics) }, func() byteComponents { return scopeMetricsComponents(scopeMetrics) })
+		for v, view := range c.views {
+			if !view.measureScopes() && !view.measureRecords() {
+				scopeLabels[v] = resourceLabels[v]
+				continue
+			}
+			var err error
+			if scopeLabels[v], err = view.scopeLabels(ctx, resourceLabels[v], scopeMetrics.Scope(), resourceMetrics.Resource(), scopeMetrics); err != nil {
+				return err
+			}
+			if !view.measureRecords() {
+				view.mapLabels(scopeLabels[v])
+				volume := dataVolume{
+					attributes: scopeLabels[v],
+					count:      scopeMetricsCount(scopeMetrics, c.countUnit),
+				}
+				view.measure(&volume, scopeSizing)
+				batch.addSpread(v, view, volume, spreads.scope(j))
+			}
+		}
 
-	for i := 0; i < traces.ResourceSpans().Len(); i++ {
-		resourceSpans := traces.ResourceSpans().At(i)
+		if !c.measureRecords() {
+			continue
+		}
+		var series []seriesSet
+		if c.countUnit == countUnitSeries || c.countUnit == countUnitMetrics {
+			series = make([]seriesSet, len(c.views))
+			for v := range series {
+				series[v] = seriesSet{}
+			}
+		}
+		samples := c.newSamples(scopeLabels)
+		for k := 0; k < scopeMetrics.Metrics().Len(); k++ {
+			if err := c.measureDataPoints(ctx, batch, scopeLabels, series, samples, timestamps, resourceMetrics, scopeMetrics, scopeMetrics.Metrics().At(k)); err != nil {
+				return err
+			}
+		}
+		batch.addSamples(samples)
+	}
+	return nil
+}
 
-		resourceAttributes := resourceSpans.Resource().Attributes()
-		rawAttributes := resourceAttributes.AsRaw()
+func (c *connectorImp) ConsumeProfiles(ctx context.Context, profiles pprofile.Profiles) error {
+	resourceProfiles := profiles.ResourceProfiles()
+	batch, err := c.measure(resourceProfiles.Len(), profiles.SampleCount(), func(batch *batch, i int) error {
+		return c.measureResourceProfiles(ctx, batch, resourceProfiles.At(i))
+	})
+	if err != nil {
+		return err
+	}
+	return c.export(ctx, batch)
+}
 
-		metricAttrMap := map[string]any{}
+// measureResourceProfiles measures a single resource of a batch for every view. Profiles are the records of the
+// profiles signal, and they are counted by their samples.
+func (c *connectorImp) measureResourceProfiles(ctx context.Context, batch *batch, resourceProfiles pprofile.ResourceProfiles) error {
+	resourceLabels := make([]pcommon.Map, len(c.views))
+	scopeLabels := make([]pcommon.Map, len(c.views))
+	timestamps := c.newTimestampChecker(batch)
+
+	for v, view := range c.views {
+		var err error
+		if resourceLabels[v], err = view.resourceLabels(ctx, resourceProfiles.Resource(), resourceProfiles, dataTypeProfilesAttributeValue); err != nil {
+			return err
+		}
+	}
 
-		metricAttrMap[dataTypeAttributeKey] = dataTypeTracesAttributeValue
-		for _, key := range c.config.LabelResourceAttributes {
-			if rawAttributes[key] != nil {
-				metricAttrMap[key] = rawAttributes[key]
+	spreads := c.spreadResourceProfiles(batch, resourceProfiles)
+	resourceSizing := newSizing(func() int { return resourceProfilesSize(resourceProfiles) }, func() byteComponents { return resourceProfilesComponents(resourceProfiles) })
+	for v, view := range c.views {
+		if view.measureScopes() || view.measureRecords() {
+			continue
+		}
+		view.mapLabels(resourceLabels[v])
+		volume := dataVolume{
+			attributes: resourceLabels[v],
+		}
+		for j := 0; j < resourceProfiles.ScopeProfiles().Len(); j++ {
+			profiles := resourceProfiles.ScopeProfiles().At(j).Profiles()
+			volume.profiles += int64(profiles.Len())
+			for k := 0; k < profiles.Len(); k++ {
+				volume.count += int64(profiles.At(k).Sample().Len())
 			}
 		}
+		view.measure(&volume, resourceSizing)
+		batch.addSpread(v, view, volume, spreads.all())
+	}
 
-		outputResourceMetrics := outputMetrics.ResourceMetrics().AppendEmpty()
-		err := outputResourceMetrics.Resource().Attributes().FromRaw(metricAttrMap)
-		if err != nil {
-			c.logger.Error("error adding attributes to datavolume metric for traces measurement", zap.Error(err), zap.Any("attributes_map", metricAttrMap))
+	if !c.measureScopes() {
+		return nil
+	}
+	for j := 0; j < resourceProfiles.ScopeProfiles().Len(); j++ {
+		scopeProfiles := resourceProfiles.ScopeProfiles().At(j)
+		scopeSizing := newSizing(func() int { return scopeProfilesSize(scopeProfiles) }, func() byteComponents { return scopeProfilesComponents(scopeProfiles) })
+		for v, view := range c.views {
+			if !view.measureScopes() && !view.measureRecords() {
+				scopeLabels[v] = resourceLabels[v]
+				continue
+			}
+			var err error
+			if scopeLabels[v], err = view.scopeLabels(ctx, resourceLabels[v], scopeProfiles.Scope(), resourceProfiles.Resource(), scopeProfiles); err != nil {
+				return err
+			}
+			if !view.measureRecords() {
+				view.mapLabels(scopeLabels[v])
+				volume := dataVolume{
+					attributes: scopeLabels[v],
+					profiles:   int64(scopeProfiles.Profiles().Len()),
+				}
+				for k := 0; k < scopeProfiles.Profiles().Len(); k++ {
+					volume.count += int64(scopeProfiles.Profiles().At(k).Sample().Len())
+				}
+				view.measure(&volume, scopeSizing)
+				batch.addSpread(v, view, volume, spreads.scope(j))
+			}
 		}
-		outputScopeMetric := outputResourceMetrics.ScopeMetrics().AppendEmpty()
 
-		if c.config.CountMetricName != "" {
-			countValue := 0
-			for j := 0; j < resourceSpans.ScopeSpans().Len(); j++ {
-				scopeSpans := resourceSpans.ScopeSpans().At(j)
-				countValue += scopeSpans.Spans().Len()
+		if !c.measureRecords() {
+			continue
+		}
+		samples := c.newSamples(scopeLabels)
+		for k := 0; k < scopeProfiles.Profiles().Len(); k++ {
+			profile := scopeProfiles.Profiles().At(k)
+			attributes := profileAttributes(profile)
+			recordSizing := newSizing(func() int { return profileSize(profile) }, func() byteComponents { return profileComponents(profile) })
+			bucket := c.bucket(batch, profile.Time())
+			record := &recordSample{sizing: recordSizing, timestamp: timestamps.check(profile.Time())}
+			for v, view := range c.views {
+				batch.sample(v, view, samples, record, bucket)
+				if !view.measureRecords() {
+					continue
+				}
+				volume := dataVolume{
+					attributes: view.recordLabels(scopeLabels[v], attributes),
+					count:      int64(profile.Sample().Len()),
+					profiles:   1,
+					bucket:     bucket,
+				}
+				view.mapLabels(volume.attributes)
+				view.measure(&volume, recordSizing)
+				view.sample(&volume, record)
+				batch.add(v, volume)
 			}
-			addOutputMetricToScopeMetrics(outputScopeMetric, c.config.CountMetricName, "", timestamp, int64(countValue))
 		}
+		batch.addSamples(samples)
+	}
+	return nil
+}
 
-		if c.config.BytesMetricName != "" {
-			isolatedPtraces := ptrace.NewTraces()
-			isolatedResourceSpans := isolatedPtraces.ResourceSpans().AppendEmpty()
-			// TODO: Opportunity for optimization here. Can we use protoreflect to measure these instead? Or add a reference instead?
-			resourceSpans.CopyTo(isolatedResourceSpans)
-			bytes := int64(ptraceSizer.TracesSize(isolatedPtraces))
-			addOutputMetricToScopeMetrics(outputScopeMetric, c.config.BytesMetricName, "bytes", timestamp, bytes)
+// profileAttributes returns the attributes of a profile, which it references by their indices in its attribute table.
+func profileAttributes(profile pprofile.Profile) pcommon.Map {
+	attributes := pcommon.NewMap()
+	table := profile.AttributeTable()
+	for i := 0; i < profile.AttributeIndices().Len(); i++ {
+		index := int(profile.AttributeIndices().At(i))
+		if index < 0 || index >= table.Len() {
+			continue
 		}
+		table.At(index).Value().CopyTo(attributes.PutEmpty(table.At(index).Key()))
 	}
+	return attributes
+}
 
-	return c.metricsConsumer.ConsumeMetrics(ctx, outputMetrics)
+// measureScopes reports whether any view measures scopes or measures or samples records one by one.
+func (c *connectorImp) measureScopes() bool {
+	for _, view := range c.views {
+		if view.measureScopes() || view.measureRecords() || view.sampleRecords() {
+			return true
+		}
+	}
+	return false
 }
 
-func (c *connectorImp) ConsumeMetrics(ctx context.Context, metrics pmetric.Metrics) error {
-	outputMetrics := pmetric.NewMetrics()
-	timestamp := pcommon.NewTimestampFromTime(time.Now())
+// measureRecords reports whether any view measures or samples log records, spans or data points one by one.
+func (c *connectorImp) measureRecords() bool {
+	for _, view := range c.views {
+		if view.measureRecords() || view.sampleRecords() {
+			return true
+		}
+	}
+	return false
+}
 
-	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
-		resourceMetrics := metrics.ResourceMetrics().At(i)
+// measureDataPoints measures each data point of the metric for every view that measures records, and adds it to the
+// samples of the views that sample them. When counting series, series holds the series each view has seen in the
+// scope, and only the first data point of a series counts it. When counting metrics, it holds the metrics each view
+// has seen in the scope per output label set, and only the first data point of a metric under a label set counts it.
+// The timestamps of the data points are checked against those before them in the resource.
+func (c *connectorImp) measureDataPoints(ctx context.Context, batch *batch, scopeLabels []pcommon.Map, series []seriesSet, samples []*dataVolume, timestamps *timestampChecker, resourceMetrics pmetric.ResourceMetrics, scopeMetrics pmetric.ScopeMetrics, metric pmetric.Metric) error {
+	add := func(dataPoint any, attributes pcommon.Map, timestamp pcommon.Timestamp, buckets int64, fieldsSize func() int) error {
+		tCtx := ottldatapoint.NewTransformContext(dataPoint, metric, scopeMetrics.Metrics(), scopeMetrics.Scope(), resourceMetrics.Resource(), scopeMetrics, resourceMetrics)
+		recordSizing := newSizing(func() int { return messageFieldSize(fieldsSize()) }, func() byteComponents { return dataPointComponents(attributes, fieldsSize()) })
+		bucket := c.bucket(batch, timestamp)
+		record := &recordSample{sizing: recordSizing, timestamp: timestamps.check(timestamp)}
+		for v, view := range c.views {
+			batch.sample(v, view, samples, record, bucket)
+			if !view.measureRecords() {
+				continue
+			}
+			if view.dataPointConditions != nil {
+				match, err := view.dataPointConditions.Eval(ctx, tCtx)
+				if err != nil {
+					return err
+				}
+				if !match {
+					continue
+				}
+			}
+			volume := dataVolume{
+				attributes: view.recordLabels(scopeLabels[v], attributes),
+				count:      1,
+				bucket:     bucket,
+			}
+			switch c.countUnit {
+			case countUnitDataPointsAndBuckets:
+				volume.count += buckets
+			case countUnitSeries:
+				if !series[v].add(metric, attributes) {
+					volume.count = 0
+				}
+			}
+			if err := putDimensions(ctx, view.dataPointDimensions, tCtx, volume.attributes, view.errorMode, view.logger); err != nil {
+				return err
+			}
+			view.mapLabels(volume.attributes)
+			if c.countUnit == countUnitMetrics && !series[v].add(metric, volume.attributes) {
+				// the metric is counted once per label set it is measured under, with its first data point there
+				volume.count = 0
+			}
+			view.measure(&volume, recordSizing)
+			view.sample(&volume, record)
+			batch.add(v, volume)
+		}
+		return nil
+	}
 
-		resourceAttributes := resourceMetrics.Resource().Attributes()
-		rawAttributes := resourceAttributes.AsRaw()
+	return forEachDataPoint(metric, add)
+}
 
-		metricAttrMap := map[string]any{}
+// batch holds the measurements of a single batch per view, merged by label set across all resources of the batch.
+type batch struct {
+	sets []*volumeSet
+	// received is the time the batch was received, which places records without a timestamp into event time buckets
+	received pcommon.Timestamp
+	// bucketed is set when measurements are bucketed by event time
+	bucketed bool
+}
 
-		metricAttrMap[dataTypeAttributeKey] = dataTypeMetricsAttributeValue
-		for _, key := range c.config.LabelResourceAttributes {
-			if rawAttributes[key] != nil {
-				metricAttrMap[key] = rawAttributes[key]
-			}
+func (c *connectorImp) newBatch(received pcommon.Timestamp) *batch {
+	sets := make([]*volumeSet, len(c.views))
+	for v := range sets {
+		sets[v] = newVolumeSet()
+	}
+	return &batch{sets: sets, received: received, bucketed: c.config.EventTime.enabled()}
+}
+
+// bucket returns the start of the event time bucket of a record with the given timestamp, or zero if measurements are
+// not bucketed by event time. Records without a timestamp are placed by the time their batch was received, and so are
+// records dated further ahead of it than the allowed clock skew, whose buckets would otherwise stay open.
+func (c *connectorImp) bucket(b *batch, timestamp pcommon.Timestamp) pcommon.Timestamp {
+	if !c.config.EventTime.enabled() {
+		return 0
+	}
+	if timestamp > b.received+pcommon.Timestamp(c.config.MaxClockSkew) {
+		timestamp = b.received
+	}
+	return c.config.EventTime.bucketStart(b.recordTime(timestamp))
+}
+
+// recordTime returns the timestamp of a record, or the time its batch was received if it has none.
+func (b *batch) recordTime(timestamp pcommon.Timestamp) pcommon.Timestamp {
+	if timestamp == 0 {
+		return b.received
+	}
+	return timestamp
+}
+
+// newSamples returns a measurement for every view that samples records, which the records of a scope are sampled into.
+// It has the label set the counts and bytes of the scope are measured under, so that the two merge into one series.
+func (c *connectorImp) newSamples(scopeLabels []pcommon.Map) []*dataVolume {
+	samples := make([]*dataVolume, len(c.views))
+	for v, view := range c.views {
+		if view.sampleRecords() {
+			samples[v] = &dataVolume{attributes: scopeLabels[v]}
 		}
+	}
+	return samples
+}
 
-		outputResourceMetrics := outputMetrics.ResourceMetrics().AppendEmpty()
-		err := outputResourceMetrics.Resource().Attributes().FromRaw(metricAttrMap)
-		if err != nil {
-			c.logger.Error("error adding attributes to datavolume metric for metrics measurement", zap.Error(err), zap.Any("attributes_map", metricAttrMap))
+// sample adds a record to the samples of its scope for a view that samples records. When bucketing by event time, the
+// record is sampled into a measurement of its own bucket instead, which the batch merges with the rest of the scope in
+// that bucket.
+func (b *batch) sample(v int, view *view, samples []*dataVolume, record *recordSample, bucket pcommon.Timestamp) {
+	if samples[v] == nil {
+		return
+	}
+	if !b.bucketed {
+		view.sample(samples[v], record)
+		return
+	}
+	volume := dataVolume{attributes: samples[v].attributes, bucket: bucket}
+	view.sample(&volume, record)
+	b.add(v, volume)
+}
+
+// addSamples adds the samples of the records of a scope to the batch, unless they were added record by record into
+// their event time buckets.
+func (b *batch) addSamples(samples []*dataVolume) {
+	if b.bucketed {
+		return
+	}
+	for v, sample := range samples {
+		if sample != nil {
+			b.add(v, *sample)
 		}
-		outputScopeMetric := outputResourceMetrics.ScopeMetrics().AppendEmpty()
+	}
+}
 
-		if c.config.CountMetricName != "" {
-			countValue := 0
-			for j := 0; j < resourceMetrics.ScopeMetrics().Len(); j++ {
-				scopeMetrics := resourceMetrics.ScopeMetrics().At(j)
-				countValue += scopeMetrics.Metrics().Len()
-			}
-			addOutputMetricToScopeMetrics(outputScopeMetric, c.config.CountMetricName, "", timestamp, int64(countValue))
+func (b *batch) add(v int, volume dataVolume) {
+	b.sets[v].add(volume)
+}
+
+// addSpread adds the measurement of a resource or scope to the batch, split across the event time buckets of its
+// records if there is a spread of them.
+func (b *batch) addSpread(v int, view *view, volume dataVolume, spread *bucketSpread) {
+	if spread == nil {
+		b.add(v, volume)
+		return
+	}
+	for _, part := range spread.split(volume, view.config.BytesMetricName != "", view.config.BytesByComponent) {
+		b.add(v, part)
+	}
+}
+
+// merge adds the measurements of other to the batch, after those it already holds.
+func (b *batch) merge(other *batch) {
+	for v, set := range other.sets {
+		for _, volume := range set.volumes() {
+			b.sets[v].add(volume)
 		}
+	}
+}
 
-		if c.config.BytesMetricName != "" {
-			isolatedPmetrics := pmetric.NewMetrics()
-			isolatedResourceMetrics := isolatedPmetrics.ResourceMetrics().AppendEmpty()
-			// TODO: Opportunity for optimization here. Can we use protoreflect to measure these instead? Or add a reference instead?
-			resourceMetrics.CopyTo(isolatedResourceMetrics)
-			bytes := int64(pmetricSizer.MetricsSize(isolatedPmetrics))
-			addOutputMetricToScopeMetrics(outputScopeMetric, c.config.BytesMetricName, "bytes", timestamp, bytes)
+// export emits the measurements right away, or hands them to the aggregators when a flush interval is configured or
+// measurements are bucketed by event time.
+func (c *connectorImp) export(ctx context.Context, batch *batch) error {
+	now := time.Now()
+	volumes := make([][]dataVolume, len(c.views))
+	for v := range c.views {
+		volumes[v] = batch.sets[v].volumes()
+	}
+
+	// late measurements are labeled by the buckets before they are limited, so they count towards the series limits
+	if c.config.EventTime.enabled() {
+		for v, view := range c.views {
+			view.buckets.add(volumes[v], now, view.limiter)
+		}
+		return nil
+	}
+	for v, view := range c.views {
+		if view.limiter != nil {
+			volumes[v] = view.limiter.limit(volumes[v], now)
+		}
+	}
+	if c.config.FlushInterval > 0 {
+		for v, view := range c.views {
+			view.aggregator.add(volumes[v])
 		}
+		return nil
+	}
+	timestamp := pcommon.NewTimestampFromTime(now)
+	output := c.newOutput()
+	for v, view := range c.views {
+		view.appendMetrics(output, volumes[v], timestamp, timestamp)
+	}
+	return c.metricsConsumer.ConsumeMetrics(ctx, output.metrics)
+}
+
+// flush emits the measurements accumulated since the last flush, or the event time buckets that have closed. On
+// shutdown, final is set and all buckets are emitted.
+func (c *connectorImp) flush(ctx context.Context, final bool) error {
+	if c.config.EventTime.enabled() {
+		return c.flushBuckets(ctx, final)
 	}
 
-	return c.metricsConsumer.ConsumeMetrics(ctx, outputMetrics)
+	windowStart := c.windowStart
+	timestamp := pcommon.NewTimestampFromTime(time.Now())
+	c.windowStart = timestamp
+
+	output := c.newOutput()
+	for _, view := range c.views {
+		view.appendMetrics(output, view.aggregator.drain(), windowStart, timestamp)
+	}
+	if output.metrics.ResourceMetrics().Len() == 0 {
+		return nil
+	}
+	return c.metricsConsumer.ConsumeMetrics(ctx, output.metrics)
 }
 
-func addOutputMetricToScopeMetrics(scopeMetric pmetric.ScopeMetrics, metricName string, unit string, timestamp pcommon.Timestamp, bytes int64) {
-	metric := scopeMetric.Metrics().AppendEmpty()
-	metric.SetName(metricName)
-	if unit != "" {
-		metric.SetUnit(unit)
+// flushBuckets emits every closed event time bucket, or all of them if all is set, stamped with the start and end of
+// the bucket.
+func (c *connectorImp) flushBuckets(ctx context.Context, all bool) error {
+	now := time.Now()
+	output := c.newOutput()
+	for _, view := range c.views {
+		for _, bucket := range view.buckets.drain(now, all) {
+			view.appendMetrics(output, bucket.volumes, bucket.start, bucket.end)
+		}
+	}
+	if output.metrics.ResourceMetrics().Len() == 0 {
+		return nil
 	}
-	sum := metric.SetEmptySum()
-	sum.SetIsMonotonic(true)
-	sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
-	dataPoints := sum.DataPoints()
-	dataPoint := dataPoints.AppendEmpty()
-	dataPoint.SetTimestamp(timestamp)
-	dataPoint.SetIntValue(bytes)
+	return c.metricsConsumer.ConsumeMetrics(ctx, output.metrics)
 }
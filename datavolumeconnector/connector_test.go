@@ -2,21 +2,67 @@ package datavolumeconnector
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/golden"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/pmetrictest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/connector/connectortest"
 	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pprofile"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/yaml.v3"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
+// ptr returns a pointer to a copy of value, for the options of signal blocks.
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// newSignalOverridesConfig returns a connector config shared by the pipelines of all signals, with signal-specific
+// metric names and labels.
+func newSignalOverridesConfig() *Config {
+	return &Config{
+		CountMetricName:         "items_total",
+		BytesMetricName:         "bytes_total",
+		LabelResourceAttributes: []string{"service.name"},
+		Signals: SignalsConfig{
+			Logs: &SignalConfig{
+				CountMetricName:       ptr("log_records_total"),
+				LabelRecordAttributes: []string{"log_level"},
+			},
+			Traces: &SignalConfig{
+				CountMetricName:         ptr("spans_total"),
+				LabelResourceAttributes: []string{"region"},
+			},
+			Profiles: &SignalConfig{
+				CountMetricName:        ptr("samples_total"),
+				ProfileCountMetricName: ptr("profiles_total"),
+				LabelRecordAttributes:  []string{"thread.name"},
+			},
+		},
+	}
+}
+
 func TestLogsToMetrics(t *testing.T) {
 	testCases := []struct {
 		name string
-		cfg  *Config
+		// input is the file in testdata the records are read from, input_logs.yaml by default
+		input string
+		cfg   *Config
 	}{
 		{
 			name: "count_service_logs",
@@ -79,6 +125,217 @@ func TestLogsToMetrics(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "count_service_region_and_missing_attribute_mapped",
+			cfg: &Config{
+				CountMetricName: "service_and_region_count_total",
+				BytesMetricName: "service_and_region_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+					"region",
+					"non_existent_attr",
+				},
+				Labels: []LabelConfig{
+					{Key: "service.name", Rename: "service", Lowercase: true},
+					{Key: "region", Extract: `^(\w)`},
+					{Key: "non_existent_attr", Default: "unknown"},
+				},
+			},
+		},
+		{
+			name: "count_service_and_log_level_normalized",
+			cfg: &Config{
+				CountMetricName:         "service_and_level_count_total",
+				LabelResourceAttributes: []string{"service.name"},
+				LabelRecordAttributes:   []string{"log_level"},
+				Labels: []LabelConfig{
+					{Key: "log_level", Rename: "level", Lowercase: true, Truncate: 4},
+				},
+			},
+		},
+		{
+			name: "count_service_and_log_level_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "service_and_log_level_count_total",
+				BytesMetricName: "service_and_log_level_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				LabelRecordAttributes: []string{
+					"log_level",
+				},
+			},
+		},
+		{
+			name: "count_service_errors_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "service_error_count_total",
+				BytesMetricName: "service_error_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				Conditions: ConditionsConfig{
+					Logs: []string{
+						`attributes["log_level"] == "ERROR"`,
+					},
+				},
+			},
+		},
+		{
+			name: "count_service_static_labels",
+			cfg: &Config{
+				CountMetricName: "service_count_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				StaticLabels: map[string]string{
+					"observer_direction": "received",
+				},
+			},
+		},
+		{
+			name: "count_attribute_patterns_bytes_and_count",
+			cfg: &Config{
+				CountMetricName:         "attribute_patterns_count_total",
+				BytesMetricName:         "attribute_patterns_byte_total",
+				LabelResourceAttributes: []string{"*"},
+				LabelRecordAttributes:   []string{"/^log_(level|severity)$/"},
+				ExcludeLabelAttributes:  []string{"reg?on"},
+			},
+		},
+		{
+			name: "count_attribute_patterns_max_matched",
+			cfg: &Config{
+				CountMetricName:         "attribute_patterns_count_total",
+				LabelResourceAttributes: []string{"/^(service|region)/"},
+				MaxMatchedAttributes:    1,
+			},
+		},
+		{
+			name: "signal_overrides",
+			cfg:  newSignalOverridesConfig(),
+		},
+		{
+			name: "signal_disables_bytes",
+			cfg: &Config{
+				CountMetricName:         "count_total",
+				BytesMetricName:         "byte_total",
+				LabelResourceAttributes: []string{"service.name"},
+				Signals: SignalsConfig{
+					Logs: &SignalConfig{BytesMetricName: ptr("")},
+				},
+			},
+		},
+		{
+			name: "count_dimensions_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "dimensions_count_total",
+				BytesMetricName: "dimensions_byte_total",
+				Dimensions: []DimensionConfig{
+					{
+						Name:  "service",
+						Value: `ConvertCase(attributes["service.name"], "lower")`,
+					},
+					{
+						Name:      "location",
+						Value:     `attributes["k8s.cluster.name"]`,
+						Fallbacks: []string{`attributes["region"]`},
+					},
+					{
+						Name:      "level",
+						Value:     `attributes["log_level"]`,
+						Fallbacks: []string{`"NONE"`},
+						Context:   "log",
+					},
+				},
+			},
+		},
+		{
+			name: "count_service_size_histogram",
+			cfg: &Config{
+				BytesMetricName:         "service_byte_total",
+				SizeHistogramMetricName: "service_record_size",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				SizeHistogram: SizeHistogramConfig{
+					Explicit: &ExplicitHistogramConfig{Buckets: []float64{32, 64, 128}},
+				},
+			},
+		},
+		{
+			name: "count_service_bytes_by_component",
+			cfg: &Config{
+				BytesMetricName:  "service_byte_total",
+				BytesByComponent: true,
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+			},
+		},
+		{
+			name: "count_service_and_log_level_max_series",
+			cfg: &Config{
+				CountMetricName: "service_and_log_level_count_total",
+				BytesMetricName: "service_and_log_level_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				LabelRecordAttributes: []string{
+					"log_level",
+				},
+				StaticLabels: map[string]string{
+					"observer_direction": "received",
+				},
+				MaxSeries: 5,
+			},
+		},
+		{
+			name: "multiple_metrics",
+			cfg: &Config{
+				CountMetricName: "log_count_total",
+				Metrics: []MetricConfig{
+					{
+						Name:        "log_bytes_by_level",
+						Kind:        "bytes",
+						Description: "OTLP bytes of log records per level",
+						Dimensions: []DimensionConfig{
+							{
+								Name:    "level",
+								Value:   `attributes["log_level"]`,
+								Context: "log",
+							},
+						},
+					},
+					{
+						Name: "error_logs_by_service",
+						Unit: "{log}",
+						Dimensions: []DimensionConfig{
+							{
+								Name:  "service",
+								Value: `attributes["service.name"]`,
+							},
+						},
+						Conditions: ConditionsConfig{
+							Logs: []string{
+								`attributes["log_level"] == "ERROR"`,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "count_service_and_severity_bytes_and_count",
+			input: "input_severity_logs.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_and_severity_count_total",
+				BytesMetricName:         "service_and_severity_byte_total",
+				LabelResourceAttributes: []string{"service.name"},
+				LabelSeverityNumber:     true,
+				LabelSeverityText:       true,
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -97,7 +354,11 @@ func TestLogsToMetrics(t *testing.T) {
 				assert.NoError(t, conn.Shutdown(context.Background()))
 			}()
 
-			testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_logs.yaml"))
+			input := testCase.input
+			if input == "" {
+				input = "input_logs.yaml"
+			}
+			testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", input))
 			assert.NoError(t, err)
 			assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
 
@@ -119,7 +380,9 @@ func TestLogsToMetrics(t *testing.T) {
 func TestTracesToMetrics(t *testing.T) {
 	testCases := []struct {
 		name string
-		cfg  *Config
+		// input is the file in testdata the records are read from, input_traces.yaml by default
+		input string
+		cfg   *Config
 	}{
 		{
 			name: "count_service_and_region_bytes_and_count",
@@ -132,6 +395,149 @@ func TestTracesToMetrics(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "count_service_and_span_attribute_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "service_and_span_attribute_count_total",
+				BytesMetricName: "service_and_span_attribute_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				LabelRecordAttributes: []string{
+					"span.required",
+				},
+			},
+		},
+		{
+			name: "count_service_foo_spans_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "service_foo_span_count_total",
+				BytesMetricName: "service_foo_span_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				Conditions: ConditionsConfig{
+					Traces: []string{
+						`name == "span-with-attrs-foo-bar"`,
+						`name == "span-with-attrs-foo-notbar"`,
+					},
+				},
+			},
+		},
+		{
+			name: "count_service_and_span_name_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "service_and_span_name_count_total",
+				BytesMetricName: "service_and_span_name_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				Dimensions: []DimensionConfig{
+					{
+						Name:    "span.name",
+						Value:   `name`,
+						Context: "span",
+					},
+				},
+			},
+		},
+		{
+			name: "signal_overrides",
+			cfg:  newSignalOverridesConfig(),
+		},
+		{
+			name: "count_service_exponential_size_histogram",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				SizeHistogramMetricName: "service_span_size",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				SizeHistogram: SizeHistogramConfig{
+					Exponential: &ExponentialHistogramConfig{MaxSize: 4},
+				},
+			},
+		},
+		{
+			name: "count_service_and_span_attribute_bytes_by_component",
+			cfg: &Config{
+				BytesMetricName:  "service_and_span_attribute_byte_total",
+				BytesByComponent: true,
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				LabelRecordAttributes: []string{
+					"span.required",
+				},
+			},
+		},
+		{
+			name:  "count_unit_spans",
+			input: "input_events_links.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				LabelResourceAttributes: []string{"service.name"},
+				Signals: SignalsConfig{
+					Traces: &SignalConfig{CountUnit: ptr(countUnitSpans)},
+				},
+			},
+		},
+		{
+			name:  "count_unit_spans_by_kind",
+			input: "input_events_links.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				LabelResourceAttributes: []string{"service.name"},
+				LabelRecordAttributes:   []string{"span.kind"},
+				Signals: SignalsConfig{
+					Traces: &SignalConfig{CountUnit: ptr(countUnitSpans)},
+				},
+			},
+		},
+		{
+			name:  "count_unit_spans_events_links",
+			input: "input_events_links.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				LabelResourceAttributes: []string{"service.name"},
+				Signals: SignalsConfig{
+					Traces: &SignalConfig{CountUnit: ptr(countUnitSpansEventsLinks)},
+				},
+			},
+		},
+		{
+			name:  "count_unit_spans_events_links_by_kind",
+			input: "input_events_links.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				LabelResourceAttributes: []string{"service.name"},
+				LabelRecordAttributes:   []string{"span.kind"},
+				Signals: SignalsConfig{
+					Traces: &SignalConfig{CountUnit: ptr(countUnitSpansEventsLinks)},
+				},
+			},
+		},
+		{
+			name:  "count_service_kind_and_status_bytes_and_count",
+			input: "input_kind_status_traces.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_kind_and_status_count_total",
+				BytesMetricName:         "service_kind_and_status_byte_total",
+				LabelResourceAttributes: []string{"service.name"},
+				LabelSpanKind:           true,
+				LabelSpanStatusCode:     true,
+			},
+		},
+		{
+			name:  "count_service_exemplars",
+			input: "input_exemplar_traces.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				BytesMetricName:         "service_byte_total",
+				LabelResourceAttributes: []string{"service.name"},
+				MaxExemplars:            2,
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -150,7 +556,11 @@ func TestTracesToMetrics(t *testing.T) {
 				assert.NoError(t, conn.Shutdown(context.Background()))
 			}()
 
-			testTraces, err := golden.ReadTraces(filepath.Join("testdata", "traces", "input_traces.yaml"))
+			input := testCase.input
+			if input == "" {
+				input = "input_traces.yaml"
+			}
+			testTraces, err := golden.ReadTraces(filepath.Join("testdata", "traces", input))
 			assert.NoError(t, err)
 			assert.NoError(t, conn.ConsumeTraces(context.Background(), testTraces))
 
@@ -172,7 +582,9 @@ func TestTracesToMetrics(t *testing.T) {
 func TestMetricsToMetrics(t *testing.T) {
 	testCases := []struct {
 		name string
-		cfg  *Config
+		// input is the file in testdata the records are read from, input_metrics.yaml by default
+		input string
+		cfg   *Config
 	}{
 		{
 			name: "count_service_and_region_bytes_and_count",
@@ -185,6 +597,150 @@ func TestMetricsToMetrics(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "count_service_and_datapoint_attribute_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "service_and_datapoint_attribute_count_total",
+				BytesMetricName: "service_and_datapoint_attribute_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				LabelRecordAttributes: []string{
+					"datapoint.required",
+				},
+			},
+		},
+		{
+			name: "count_service_foo_datapoints_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "service_foo_datapoint_count_total",
+				BytesMetricName: "service_foo_datapoint_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				Conditions: ConditionsConfig{
+					Metrics: []string{
+						`attributes["datapoint.required"] == "foo"`,
+					},
+				},
+			},
+		},
+		{
+			name: "count_service_and_metric_name_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "service_and_metric_name_count_total",
+				BytesMetricName: "service_and_metric_name_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				Dimensions: []DimensionConfig{
+					{
+						Name:    "metric.name",
+						Value:   `metric.name`,
+						Context: "datapoint",
+					},
+				},
+			},
+		},
+		{
+			name: "count_service_bytes_by_component",
+			cfg: &Config{
+				BytesMetricName:  "service_byte_total",
+				BytesByComponent: true,
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+			},
+		},
+		{
+			name:  "count_unit_metrics",
+			input: "input_count_units.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				CountUnit:               countUnitMetrics,
+				LabelResourceAttributes: []string{"service.name"},
+			},
+		},
+		{
+			name:  "count_unit_metrics_by_method",
+			input: "input_count_units.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				CountUnit:               countUnitMetrics,
+				LabelResourceAttributes: []string{"service.name"},
+				LabelRecordAttributes:   []string{"http.method"},
+			},
+		},
+		{
+			name:  "count_unit_data_points",
+			input: "input_count_units.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				CountUnit:               countUnitDataPoints,
+				LabelResourceAttributes: []string{"service.name"},
+			},
+		},
+		{
+			name:  "count_unit_data_points_by_method",
+			input: "input_count_units.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				CountUnit:               countUnitDataPoints,
+				LabelResourceAttributes: []string{"service.name"},
+				LabelRecordAttributes:   []string{"http.method"},
+			},
+		},
+		{
+			name:  "count_unit_series",
+			input: "input_count_units.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				CountUnit:               countUnitSeries,
+				LabelResourceAttributes: []string{"service.name"},
+			},
+		},
+		{
+			name:  "count_unit_series_by_method",
+			input: "input_count_units.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				CountUnit:               countUnitSeries,
+				LabelResourceAttributes: []string{"service.name"},
+				LabelRecordAttributes:   []string{"http.method"},
+			},
+		},
+		{
+			name:  "count_unit_data_points_and_buckets",
+			input: "input_count_units.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				CountUnit:               countUnitDataPointsAndBuckets,
+				LabelResourceAttributes: []string{"service.name"},
+			},
+		},
+		{
+			name:  "count_unit_data_points_and_buckets_by_method",
+			input: "input_count_units.yaml",
+			cfg: &Config{
+				CountMetricName:         "service_count_total",
+				CountUnit:               countUnitDataPointsAndBuckets,
+				LabelResourceAttributes: []string{"service.name"},
+				LabelRecordAttributes:   []string{"http.method"},
+			},
+		},
+		{
+			name: "count_metric_name_and_type_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "metric_name_and_type_count_total",
+				BytesMetricName: "metric_name_and_type_byte_total",
+				Signals: SignalsConfig{
+					Metrics: &SignalConfig{
+						LabelMetricName: ptr(true),
+						LabelMetricType: ptr(true),
+					},
+				},
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -203,7 +759,11 @@ func TestMetricsToMetrics(t *testing.T) {
 				assert.NoError(t, conn.Shutdown(context.Background()))
 			}()
 
-			testMetrics, err := golden.ReadMetrics(filepath.Join("testdata", "metrics", "input_metrics.yaml"))
+			input := testCase.input
+			if input == "" {
+				input = "input_metrics.yaml"
+			}
+			testMetrics, err := golden.ReadMetrics(filepath.Join("testdata", "metrics", input))
 			assert.NoError(t, err)
 			assert.NoError(t, conn.ConsumeMetrics(context.Background(), testMetrics))
 
@@ -221,3 +781,1037 @@ func TestMetricsToMetrics(t *testing.T) {
 		})
 	}
 }
+
+// readProfiles reads profiles from a YAML file in the OTLP JSON layout, which the golden package cannot read yet.
+func readProfiles(filePath string) (pprofile.Profiles, error) {
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return pprofile.Profiles{}, err
+	}
+	var m map[string]any
+	if err = yaml.Unmarshal(b, &m); err != nil {
+		return pprofile.Profiles{}, err
+	}
+	if b, err = json.Marshal(m); err != nil {
+		return pprofile.Profiles{}, err
+	}
+	return (&pprofile.JSONUnmarshaler{}).UnmarshalProfiles(b)
+}
+
+func TestProfilesToMetrics(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  *Config
+	}{
+		{
+			name: "count_service_and_region_bytes_and_count",
+			cfg: &Config{
+				CountMetricName:        "service_and_region_sample_total",
+				ProfileCountMetricName: "service_and_region_profile_total",
+				BytesMetricName:        "service_and_region_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+					"region",
+				},
+			},
+		},
+		{
+			name: "count_service_and_scope_bytes_and_count",
+			cfg: &Config{
+				CountMetricName:        "service_and_scope_sample_total",
+				ProfileCountMetricName: "service_and_scope_profile_total",
+				BytesMetricName:        "service_and_scope_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				LabelScopeVersion: true,
+			},
+		},
+		{
+			name: "count_service_and_thread_bytes_and_count",
+			cfg: &Config{
+				CountMetricName:        "service_and_thread_sample_total",
+				ProfileCountMetricName: "service_and_thread_profile_total",
+				BytesMetricName:        "service_and_thread_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				LabelRecordAttributes: []string{
+					"thread.name",
+				},
+			},
+		},
+		{
+			name: "signal_overrides",
+			cfg:  newSignalOverridesConfig(),
+		},
+		{
+			name: "count_service_size_histogram",
+			cfg: &Config{
+				BytesMetricName:         "service_byte_total",
+				SizeHistogramMetricName: "service_profile_size",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				SizeHistogram: SizeHistogramConfig{
+					Explicit: &ExplicitHistogramConfig{Buckets: []float64{64, 128, 256}},
+				},
+			},
+		},
+		{
+			name: "count_service_bytes_by_component",
+			cfg: &Config{
+				BytesMetricName:  "service_byte_total",
+				BytesByComponent: true,
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.NoError(t, testCase.cfg.Validate())
+			factory := NewFactory()
+			metricsSink := &consumertest.MetricsSink{}
+			conn, err := factory.CreateProfilesToMetrics(context.Background(),
+				connectortest.NewNopSettings(), testCase.cfg, metricsSink)
+			require.NoError(t, err)
+			require.NotNil(t, conn)
+			assert.False(t, conn.Capabilities().MutatesData)
+
+			require.NoError(t, conn.Start(context.Background(), componenttest.NewNopHost()))
+			defer func() {
+				assert.NoError(t, conn.Shutdown(context.Background()))
+			}()
+
+			testProfiles, err := readProfiles(filepath.Join("testdata", "profiles", "input_profiles.yaml"))
+			assert.NoError(t, err)
+			assert.NoError(t, conn.ConsumeProfiles(context.Background(), testProfiles))
+
+			allMetrics := metricsSink.AllMetrics()
+			assert.Len(t, allMetrics, 1)
+
+			expected, err := golden.ReadMetrics(filepath.Join("testdata", "profiles", testCase.name+".yaml"))
+			assert.NoError(t, err)
+			assert.NoError(t, pmetrictest.CompareMetrics(expected, allMetrics[0],
+				pmetrictest.IgnoreTimestamp(),
+				pmetrictest.IgnoreStartTimestamp(),
+				pmetrictest.IgnoreResourceMetricsOrder(),
+				pmetrictest.IgnoreMetricsOrder(),
+				pmetrictest.IgnoreMetricDataPointsOrder()))
+		})
+	}
+}
+
+func TestCountUnitOfOtherSignal(t *testing.T) {
+	cfg := &Config{
+		CountMetricName: "count_total",
+		CountUnit:       countUnitDataPoints,
+	}
+	require.NoError(t, cfg.Validate())
+	_, err := NewFactory().CreateLogsToMetrics(context.Background(), connectortest.NewNopSettings(), cfg, consumertest.NewNop())
+	assert.EqualError(t, err, `count_unit of logs must be "log_records", got "data_points"`)
+}
+
+func TestProfilesIgnoreRecordOptions(t *testing.T) {
+	cfg := &Config{
+		CountMetricName: "count_total",
+		Conditions:      ConditionsConfig{Logs: []string{`severity_number >= SEVERITY_NUMBER_ERROR`}},
+	}
+	require.NoError(t, cfg.Validate())
+	core, logs := observer.New(zap.WarnLevel)
+	set := connectortest.NewNopSettings()
+	set.Logger = zap.New(core)
+	_, err := NewFactory().CreateProfilesToMetrics(context.Background(), set, cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 1, logs.FilterMessageSnippet("not supported for profiles").Len())
+}
+
+func TestLogsToMetricsScopes(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  *Config
+	}{
+		{
+			name: "count_service_and_scope_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "service_and_scope_count_total",
+				BytesMetricName: "service_and_scope_byte_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				LabelScopeName: true,
+			},
+		},
+		{
+			name: "attributes_service_and_scope_bytes_and_count",
+			cfg: &Config{
+				CountMetricName:         "service_and_scope_count_total",
+				BytesMetricName:         "service_and_scope_byte_total",
+				BytesByComponent:        true,
+				SizeHistogramMetricName: "service_and_scope_record_size",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				LabelScopeName: true,
+				Output:         OutputConfig{Labels: outputLabelsAttributes},
+				DataType:       DataTypeConfig{Key: "signal", Logs: "log"},
+			},
+		},
+		{
+			name: "attributes_service_without_data_type",
+			cfg: &Config{
+				CountMetricName: "service_count_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+				},
+				Output:   OutputConfig{Labels: outputLabelsAttributes},
+				DataType: DataTypeConfig{Disabled: true},
+			},
+		},
+		{
+			name: "count_scope_version_and_attribute_bytes_and_count",
+			cfg: &Config{
+				CountMetricName:   "scope_version_and_attribute_count_total",
+				BytesMetricName:   "scope_version_and_attribute_byte_total",
+				LabelScopeName:    true,
+				LabelScopeVersion: true,
+				LabelScopeAttributes: []string{
+					"team",
+				},
+			},
+		},
+		{
+			name: "count_scope_and_log_level_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "scope_and_log_level_count_total",
+				BytesMetricName: "scope_and_log_level_byte_total",
+				LabelScopeName:  true,
+				LabelRecordAttributes: []string{
+					"log_level",
+				},
+			},
+		},
+		{
+			name: "count_scope_dimensions_bytes_and_count",
+			cfg: &Config{
+				CountMetricName: "scope_dimensions_count_total",
+				BytesMetricName: "scope_dimensions_byte_total",
+				Dimensions: []DimensionConfig{
+					{
+						Name:    "library",
+						Value:   `Concat([name, version], "@")`,
+						Context: "scope",
+					},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.NoError(t, testCase.cfg.Validate())
+			factory := NewFactory()
+			metricsSink := &consumertest.MetricsSink{}
+			conn, err := factory.CreateLogsToMetrics(context.Background(),
+				connectortest.NewNopSettings(), testCase.cfg, metricsSink)
+			require.NoError(t, err)
+			require.NoError(t, conn.Start(context.Background(), componenttest.NewNopHost()))
+			defer func() {
+				assert.NoError(t, conn.Shutdown(context.Background()))
+			}()
+
+			testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_scope_logs.yaml"))
+			require.NoError(t, err)
+			assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+
+			allMetrics := metricsSink.AllMetrics()
+			require.Len(t, allMetrics, 1)
+
+			expected, err := golden.ReadMetrics(filepath.Join("testdata", "logs", testCase.name+".yaml"))
+			assert.NoError(t, err)
+			assert.NoError(t, pmetrictest.CompareMetrics(expected, allMetrics[0],
+				pmetrictest.IgnoreTimestamp(),
+				pmetrictest.IgnoreStartTimestamp(),
+				pmetrictest.IgnoreResourceMetricsOrder(),
+				pmetrictest.IgnoreMetricsOrder(),
+				pmetrictest.IgnoreMetricDataPointsOrder()))
+		})
+	}
+}
+
+func TestLogsToMetricsNestedAttributes(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  *Config
+	}{
+		{
+			name: "count_pod_team_and_http_method",
+			cfg: &Config{
+				CountMetricName: "team_and_method_count_total",
+				LabelResourceAttributes: []string{
+					"k8s.pod.labels.team",
+					"cloud.zones.0",
+				},
+				LabelRecordAttributes: []string{
+					"http.method",
+				},
+			},
+		},
+		{
+			name: "count_pod_labels_json",
+			cfg: &Config{
+				CountMetricName: "pod_labels_count_total",
+				LabelResourceAttributes: []string{
+					"k8s.pod.labels",
+				},
+			},
+		},
+		{
+			name: "count_pod_labels_joined",
+			cfg: &Config{
+				CountMetricName: "pod_labels_count_total",
+				LabelResourceAttributes: []string{
+					"k8s.pod.labels",
+					"cloud.zones",
+				},
+				ComplexValues: complexValuesJoin,
+			},
+		},
+		{
+			name: "count_pod_labels_dropped",
+			cfg: &Config{
+				CountMetricName: "pod_labels_count_total",
+				LabelResourceAttributes: []string{
+					"service.name",
+					"k8s.pod.labels",
+				},
+				ComplexValues: complexValuesDrop,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.NoError(t, testCase.cfg.Validate())
+			metricsSink := &consumertest.MetricsSink{}
+			conn, err := NewFactory().CreateLogsToMetrics(context.Background(),
+				connectortest.NewNopSettings(), testCase.cfg, metricsSink)
+			require.NoError(t, err)
+
+			testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_nested_logs.yaml"))
+			require.NoError(t, err)
+			assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+
+			allMetrics := metricsSink.AllMetrics()
+			assert.Len(t, allMetrics, 1)
+
+			expected, err := golden.ReadMetrics(filepath.Join("testdata", "logs", testCase.name+".yaml"))
+			assert.NoError(t, err)
+			assert.NoError(t, pmetrictest.CompareMetrics(expected, allMetrics[0],
+				pmetrictest.IgnoreTimestamp(),
+				pmetrictest.IgnoreStartTimestamp(),
+				pmetrictest.IgnoreResourceMetricsOrder(),
+				pmetrictest.IgnoreMetricsOrder(),
+				pmetrictest.IgnoreMetricDataPointsOrder()))
+		})
+	}
+}
+
+func TestLogsToMetricsFlushInterval(t *testing.T) {
+	cfg := &Config{
+		CountMetricName: "service_count_total",
+		BytesMetricName: "service_byte_total",
+		LabelResourceAttributes: []string{
+			"service.name",
+		},
+		FlushInterval: time.Hour,
+	}
+	require.NoError(t, cfg.Validate())
+	factory := NewFactory()
+	metricsSink := &consumertest.MetricsSink{}
+	conn, err := factory.CreateLogsToMetrics(context.Background(),
+		connectortest.NewNopSettings(), cfg, metricsSink)
+	require.NoError(t, err)
+	require.NoError(t, conn.Start(context.Background(), componenttest.NewNopHost()))
+
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_logs.yaml"))
+	require.NoError(t, err)
+	assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+	assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+	assert.Empty(t, metricsSink.AllMetrics())
+
+	require.NoError(t, conn.Shutdown(context.Background()))
+	allMetrics := metricsSink.AllMetrics()
+	require.Len(t, allMetrics, 1)
+
+	expected, err := golden.ReadMetrics(filepath.Join("testdata", "logs", "flush_interval_service_bytes_and_count.yaml"))
+	assert.NoError(t, err)
+	assert.NoError(t, pmetrictest.CompareMetrics(expected, allMetrics[0],
+		pmetrictest.IgnoreTimestamp(),
+		pmetrictest.IgnoreStartTimestamp(),
+		pmetrictest.IgnoreResourceMetricsOrder(),
+		pmetrictest.IgnoreMetricsOrder(),
+		pmetrictest.IgnoreMetricDataPointsOrder()))
+}
+
+func TestLogsToMetricsEventTime(t *testing.T) {
+	cfg := &Config{
+		CountMetricName: "service_count_total",
+		LabelResourceAttributes: []string{
+			"service.name",
+		},
+		EventTime:    EventTimeConfig{Interval: time.Hour, AllowedLateness: 2 * time.Hour},
+		MaxClockSkew: time.Minute,
+		Output:       OutputConfig{Labels: outputLabelsAttributes},
+	}
+	require.NoError(t, cfg.Validate())
+	metricsSink := &consumertest.MetricsSink{}
+	conn, err := NewFactory().CreateLogsToMetrics(context.Background(),
+		connectortest.NewNopSettings(), cfg, metricsSink)
+	require.NoError(t, err)
+	require.NoError(t, conn.Start(context.Background(), componenttest.NewNopHost()))
+
+	now := time.Now()
+	testLogs := plog.NewLogs()
+	resourceLogs := testLogs.ResourceLogs().AppendEmpty()
+	resourceLogs.Resource().Attributes().PutStr("service.name", "serviceA")
+	records := resourceLogs.ScopeLogs().AppendEmpty().LogRecords()
+	// the previous bucket, the current one, a bucket that has closed, a record without timestamps and a future-dated one
+	for _, timestamp := range []time.Time{now.Add(-time.Hour), now, now.Add(-5 * time.Hour), {}, now.Add(5 * time.Hour)} {
+		record := records.AppendEmpty()
+		record.Body().SetStr("event")
+		if !timestamp.IsZero() {
+			record.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+		}
+	}
+	assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+	assert.Empty(t, metricsSink.AllMetrics())
+
+	require.NoError(t, conn.Shutdown(context.Background()))
+	allMetrics := metricsSink.AllMetrics()
+	require.Len(t, allMetrics, 1)
+
+	type series struct {
+		start, end time.Time
+		late       bool
+	}
+	counts := map[series]int64{}
+	resourceMetrics := allMetrics[0].ResourceMetrics()
+	for i := 0; i < resourceMetrics.Len(); i++ {
+		dataPoints := resourceMetrics.At(i).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+		for j := 0; j < dataPoints.Len(); j++ {
+			dataPoint := dataPoints.At(j)
+			_, late := dataPoint.Attributes().Get(lateAttributeKey)
+			counts[series{
+				start: dataPoint.StartTimestamp().AsTime(),
+				end:   dataPoint.Timestamp().AsTime(),
+				late:  late,
+			}] += dataPoint.IntValue()
+		}
+	}
+	current := now.UTC().Truncate(time.Hour)
+	previous := current.Add(-time.Hour)
+	assert.Equal(t, map[series]int64{
+		{start: previous, end: current}:                           1,
+		{start: current, end: current.Add(time.Hour)}:             3,
+		{start: current, end: current.Add(time.Hour), late: true}: 1,
+	}, counts)
+}
+
+func TestLogsToMetricsTimestamps(t *testing.T) {
+	cfg := &Config{
+		LagHistogramMetricName: "service_lag",
+		LagHistogram: SizeHistogramConfig{
+			Explicit: &ExplicitHistogramConfig{Buckets: []float64{1000, 60000, 3600000}},
+		},
+		TimestampAnomalyMetricName: "service_timestamp_anomalies_total",
+		LabelResourceAttributes: []string{
+			"service.name",
+		},
+		MaxClockSkew:  time.Minute,
+		MaxOutOfOrder: 5 * time.Minute,
+		Output:        OutputConfig{Labels: outputLabelsAttributes},
+	}
+	require.NoError(t, cfg.Validate())
+	metricsSink := &consumertest.MetricsSink{}
+	conn, err := NewFactory().CreateLogsToMetrics(context.Background(),
+		connectortest.NewNopSettings(), cfg, metricsSink)
+	require.NoError(t, err)
+
+	now := time.Now()
+	testLogs := plog.NewLogs()
+	resourceLogs := testLogs.ResourceLogs().AppendEmpty()
+	resourceLogs.Resource().Attributes().PutStr("service.name", "serviceA")
+	records := resourceLogs.ScopeLogs().AppendEmpty().LogRecords()
+	// a fresh record, one out of order behind it, one without timestamps, a future-dated one and another fresh one
+	for _, timestamp := range []time.Time{now.Add(-5 * time.Second), now.Add(-10 * time.Minute), {}, now.Add(time.Hour), now.Add(-30 * time.Second)} {
+		record := records.AppendEmpty()
+		record.Body().SetStr("event")
+		if !timestamp.IsZero() {
+			record.SetTimestamp(pcommon.NewTimestampFromTime(timestamp))
+		}
+	}
+	assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+
+	allMetrics := metricsSink.AllMetrics()
+	require.Len(t, allMetrics, 1)
+	metrics := allMetrics[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 2, metrics.Len())
+
+	lag := metrics.At(0)
+	assert.Equal(t, "service_lag", lag.Name())
+	assert.Equal(t, "ms", lag.Unit())
+	require.Equal(t, 1, lag.Histogram().DataPoints().Len())
+	assert.Equal(t, []uint64{0, 2, 1, 0}, lag.Histogram().DataPoints().At(0).BucketCounts().AsRaw())
+
+	anomalies := map[string]int64{}
+	dataPoints := metrics.At(1).Sum().DataPoints()
+	for i := 0; i < dataPoints.Len(); i++ {
+		anomaly, _ := dataPoints.At(i).Attributes().Get(timestampAnomalyAttributeKey)
+		anomalies[anomaly.Str()] = dataPoints.At(i).IntValue()
+	}
+	assert.Equal(t, map[string]int64{"zero": 1, "future": 1, "out_of_order": 1}, anomalies)
+}
+
+func TestLogsToMetricsCollectorResource(t *testing.T) {
+	cfg := &Config{
+		CountMetricName: "service_count_total",
+		LabelResourceAttributes: []string{
+			"service.name",
+		},
+		Output: OutputConfig{Labels: outputLabelsAttributes, Resource: outputResourceCollector},
+	}
+	require.NoError(t, cfg.Validate())
+	set := connectortest.NewNopSettings()
+	set.Resource.Attributes().PutStr("service.name", "otelcol")
+	set.Resource.Attributes().PutStr("service.instance.id", "instance-1")
+	metricsSink := &consumertest.MetricsSink{}
+	conn, err := NewFactory().CreateLogsToMetrics(context.Background(), set, cfg, metricsSink)
+	require.NoError(t, err)
+
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_scope_logs.yaml"))
+	require.NoError(t, err)
+	assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+
+	allMetrics := metricsSink.AllMetrics()
+	require.Len(t, allMetrics, 1)
+	require.Equal(t, 1, allMetrics[0].ResourceMetrics().Len())
+	resourceMetrics := allMetrics[0].ResourceMetrics().At(0)
+	assert.Equal(t, set.Resource.Attributes().AsRaw(), resourceMetrics.Resource().Attributes().AsRaw())
+	dataPoints := resourceMetrics.ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+	require.Equal(t, 2, dataPoints.Len())
+	assert.Equal(t, map[string]any{"data_type": "logs", "service.name": "serviceA"}, dataPoints.At(0).Attributes().AsRaw())
+	assert.Equal(t, map[string]any{"data_type": "logs", "service.name": "serviceB"}, dataPoints.At(1).Attributes().AsRaw())
+}
+
+func TestLogsToMetricsIdentityLabels(t *testing.T) {
+	cfg := &Config{
+		CountMetricName: "count_total",
+		IdentityLabels: IdentityLabelsConfig{
+			ComponentID:       true,
+			ServiceInstanceID: true,
+			HostName:          true,
+		},
+		Output: OutputConfig{Labels: outputLabelsAttributes},
+	}
+	require.NoError(t, cfg.Validate())
+	set := connectortest.NewNopSettings()
+	set.ID = component.MustNewIDWithName("datavolume", "received")
+	set.Resource.Attributes().PutStr("service.instance.id", "instance-1")
+	set.Resource.Attributes().PutStr("host.name", "node-1")
+	metricsSink := &consumertest.MetricsSink{}
+	conn, err := NewFactory().CreateLogsToMetrics(context.Background(), set, cfg, metricsSink)
+	require.NoError(t, err)
+
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_logs.yaml"))
+	require.NoError(t, err)
+	assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+
+	allMetrics := metricsSink.AllMetrics()
+	require.Len(t, allMetrics, 1)
+	dataPoints := allMetrics[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+	require.Positive(t, dataPoints.Len())
+	assert.Equal(t, map[string]any{
+		"data_type":            "logs",
+		"otelcol.component.id": "datavolume/received",
+		"service.instance.id":  "instance-1",
+		"host.name":            "node-1",
+	}, dataPoints.At(0).Attributes().AsRaw())
+}
+
+func TestLogsToMetricsCumulative(t *testing.T) {
+	cfg := &Config{
+		CountMetricName: "service_count_total",
+		BytesMetricName: "service_byte_total",
+		LabelResourceAttributes: []string{
+			"service.name",
+		},
+		FlushInterval:    time.Hour,
+		Temporality:      temporalityCumulative,
+		SeriesExpiration: time.Hour,
+	}
+	require.NoError(t, cfg.Validate())
+	factory := NewFactory()
+	metricsSink := &consumertest.MetricsSink{}
+	conn, err := factory.CreateLogsToMetrics(context.Background(),
+		connectortest.NewNopSettings(), cfg, metricsSink)
+	require.NoError(t, err)
+	require.NoError(t, conn.Start(context.Background(), componenttest.NewNopHost()))
+
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_logs.yaml"))
+	require.NoError(t, err)
+	assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+	require.NoError(t, conn.(*connectorImp).flush(context.Background(), false))
+	assert.NoError(t, conn.ConsumeLogs(context.Background(), testLogs))
+	require.NoError(t, conn.Shutdown(context.Background()))
+
+	allMetrics := metricsSink.AllMetrics()
+	require.Len(t, allMetrics, 2)
+
+	expected, err := golden.ReadMetrics(filepath.Join("testdata", "logs", "cumulative_service_bytes_and_count.yaml"))
+	assert.NoError(t, err)
+	assert.NoError(t, pmetrictest.CompareMetrics(expected, allMetrics[1],
+		pmetrictest.IgnoreTimestamp(),
+		pmetrictest.IgnoreStartTimestamp(),
+		pmetrictest.IgnoreResourceMetricsOrder(),
+		pmetrictest.IgnoreMetricsOrder(),
+		pmetrictest.IgnoreMetricDataPointsOrder()))
+
+	first := allMetrics[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	second := allMetrics[1].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	assert.NotZero(t, first.StartTimestamp())
+	assert.Equal(t, first.StartTimestamp(), second.StartTimestamp())
+	assert.Equal(t, 2*first.IntValue(), second.IntValue())
+}
+
+func TestSeriesTrackerExpiration(t *testing.T) {
+	attributes := pcommon.NewMap()
+	attributes.PutStr("service.name", "serviceA")
+	volume := dataVolume{attributes: attributes, count: 2, bytes: 100}
+	at := func(seconds int64) pcommon.Timestamp {
+		return pcommon.NewTimestampFromTime(time.Unix(seconds, 0))
+	}
+
+	cumulative := newSeriesTracker(temporalityCumulative, time.Minute, false)
+	start, totals := cumulative.record(volume, at(0), at(10))
+	assert.Equal(t, at(0), start)
+	assert.Equal(t, int64(2), totals.count)
+	assert.Equal(t, int64(100), totals.bytes)
+
+	start, totals = cumulative.record(volume, at(10), at(20))
+	assert.Equal(t, at(0), start)
+	assert.Equal(t, int64(4), totals.count)
+	assert.Equal(t, int64(200), totals.bytes)
+
+	cumulative.sweep(at(200).AsTime())
+	start, totals = cumulative.record(volume, at(190), at(200))
+	assert.Equal(t, at(190), start)
+	assert.Equal(t, int64(2), totals.count)
+	assert.Equal(t, int64(100), totals.bytes)
+
+	delta := newSeriesTracker(temporalityDelta, time.Minute, false)
+	start, totals = delta.record(volume, at(0), at(10))
+	assert.Equal(t, at(0), start)
+	assert.Equal(t, int64(2), totals.count)
+	start, totals = delta.record(volume, at(20), at(30))
+	assert.Equal(t, at(10), start)
+	assert.Equal(t, int64(2), totals.count)
+
+	// event time buckets start at their own beginning, also when there is a gap before them
+	buckets := newSeriesTracker(temporalityDelta, time.Minute, true)
+	start, _ = buckets.record(volume, at(0), at(60))
+	assert.Equal(t, at(0), start)
+	start, _ = buckets.record(volume, at(1800), at(1860))
+	assert.Equal(t, at(1800), start)
+}
+
+func TestLogsToMetricsConditionErrorMode(t *testing.T) {
+	testCases := []struct {
+		name        string
+		errorMode   ottl.ErrorMode
+		parallelism int
+		wantErr     bool
+	}{
+		{
+			name:      "propagate",
+			errorMode: ottl.PropagateError,
+			wantErr:   true,
+		},
+		{
+			name:        "propagate_parallel",
+			errorMode:   ottl.PropagateError,
+			parallelism: 2,
+			wantErr:     true,
+		},
+		{
+			name:      "ignore",
+			errorMode: ottl.IgnoreError,
+		},
+		{
+			name:      "silent",
+			errorMode: ottl.SilentError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cfg := &Config{
+				CountMetricName: "count_total",
+				Conditions: ConditionsConfig{
+					Logs: []string{
+						`ParseJSON(body) != nil`,
+					},
+				},
+				ErrorMode:   testCase.errorMode,
+				Parallelism: testCase.parallelism,
+			}
+			require.NoError(t, cfg.Validate())
+			metricsSink := &consumertest.MetricsSink{}
+			conn, err := NewFactory().CreateLogsToMetrics(context.Background(),
+				connectortest.NewNopSettings(), cfg, metricsSink)
+			require.NoError(t, err)
+
+			testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_logs.yaml"))
+			require.NoError(t, err)
+			err = conn.ConsumeLogs(context.Background(), testLogs)
+			if testCase.wantErr {
+				assert.Error(t, err)
+				assert.Empty(t, metricsSink.AllMetrics())
+				return
+			}
+			assert.NoError(t, err)
+			require.Len(t, metricsSink.AllMetrics(), 1)
+			assert.Equal(t, 0, metricsSink.AllMetrics()[0].DataPointCount())
+		})
+	}
+}
+
+// The ranges of a batch are measured into batches of their own, which must all share the time the batch was received.
+func TestParallelMeasurementReceivedTime(t *testing.T) {
+	c, err := newConnector(connectortest.NewNopSettings(), &Config{CountMetricName: "count_total", Parallelism: 4}, dataTypeLogsAttributeValue)
+	require.NoError(t, err)
+
+	received := make([]pcommon.Timestamp, 64)
+	_, err = c.measure(len(received), len(received), func(batch *batch, i int) error {
+		received[i] = batch.received
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	require.NoError(t, err)
+	for _, timestamp := range received {
+		assert.Equal(t, received[0], timestamp)
+	}
+}
+
+// Measuring a batch in parallel must not change the output, not even the order of its series.
+func TestParallelMeasurement(t *testing.T) {
+	resourceCfg := Config{
+		CountMetricName:         "count_total",
+		BytesMetricName:         "byte_total",
+		BytesByComponent:        true,
+		SizeHistogramMetricName: "size",
+		LabelResourceAttributes: []string{"service.name"},
+		Metrics: []MetricConfig{
+			{Name: "scope_count_total", Dimensions: []DimensionConfig{{Name: "scope", Value: `name`, Context: "scope"}}},
+		},
+	}
+	recordCfg := resourceCfg
+	recordCfg.LabelRecordAttributes = []string{"host.name", "http.route"}
+
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_scope_logs.yaml"))
+	require.NoError(t, err)
+	testTraces, err := golden.ReadTraces(filepath.Join("testdata", "traces", "input_traces.yaml"))
+	require.NoError(t, err)
+	testMetrics, err := golden.ReadMetrics(filepath.Join("testdata", "metrics", "input_metrics.yaml"))
+	require.NoError(t, err)
+	logs, traces, metrics := plog.NewLogs(), ptrace.NewTraces(), pmetric.NewMetrics()
+	for r := 0; r < 25; r++ {
+		for i := 0; i < testLogs.ResourceLogs().Len(); i++ {
+			testLogs.ResourceLogs().At(i).CopyTo(logs.ResourceLogs().AppendEmpty())
+		}
+		for i := 0; i < testTraces.ResourceSpans().Len(); i++ {
+			testTraces.ResourceSpans().At(i).CopyTo(traces.ResourceSpans().AppendEmpty())
+		}
+		for i := 0; i < testMetrics.ResourceMetrics().Len(); i++ {
+			testMetrics.ResourceMetrics().At(i).CopyTo(metrics.ResourceMetrics().AppendEmpty())
+		}
+	}
+
+	for _, testCase := range []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "resources", cfg: resourceCfg},
+		{name: "records", cfg: recordCfg},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			consume := func(t *testing.T, parallelism int) []pmetric.Metrics {
+				cfg := testCase.cfg
+				cfg.Parallelism = parallelism
+				cfg.ParallelismThreshold = 1
+				require.NoError(t, cfg.Validate())
+				metricsSink := &consumertest.MetricsSink{}
+				factory := NewFactory()
+				logsConn, err := factory.CreateLogsToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+				require.NoError(t, err)
+				require.NoError(t, logsConn.ConsumeLogs(context.Background(), logs))
+				tracesConn, err := factory.CreateTracesToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+				require.NoError(t, err)
+				require.NoError(t, tracesConn.ConsumeTraces(context.Background(), traces))
+				metricsConn, err := factory.CreateMetricsToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+				require.NoError(t, err)
+				require.NoError(t, metricsConn.ConsumeMetrics(context.Background(), metrics))
+				return metricsSink.AllMetrics()
+			}
+
+			expected := consume(t, 0)
+			require.Len(t, expected, 3)
+			for _, parallelism := range []int{2, 4, 16, 1000} {
+				actual := consume(t, parallelism)
+				require.Len(t, actual, 3)
+				for i := range expected {
+					assert.NoError(t, pmetrictest.CompareMetrics(expected[i], actual[i],
+						pmetrictest.IgnoreTimestamp(),
+						pmetrictest.IgnoreStartTimestamp()), "parallelism %d", parallelism)
+				}
+			}
+		})
+	}
+}
+
+// Sampling records for histograms must not change the counts and bytes, which are still measured per resource.
+func TestSampledRecordsKeepSums(t *testing.T) {
+	baseCfg := Config{
+		CountMetricName:         "count_total",
+		BytesMetricName:         "byte_total",
+		LabelResourceAttributes: []string{"service.name"},
+	}
+
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_logs.yaml"))
+	require.NoError(t, err)
+	testTraces, err := golden.ReadTraces(filepath.Join("testdata", "traces", "input_exemplar_traces.yaml"))
+	require.NoError(t, err)
+	testMetrics, err := golden.ReadMetrics(filepath.Join("testdata", "metrics", "input_metrics.yaml"))
+	require.NoError(t, err)
+
+	// sums returns the values of the count and bytes sums by metric name and label set
+	sums := func(t *testing.T, cfg Config) map[string]int64 {
+		require.NoError(t, cfg.Validate())
+		metricsSink := &consumertest.MetricsSink{}
+		factory := NewFactory()
+		logsConn, err := factory.CreateLogsToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+		require.NoError(t, err)
+		require.NoError(t, logsConn.ConsumeLogs(context.Background(), testLogs))
+		tracesConn, err := factory.CreateTracesToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+		require.NoError(t, err)
+		require.NoError(t, tracesConn.ConsumeTraces(context.Background(), testTraces))
+		metricsConn, err := factory.CreateMetricsToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+		require.NoError(t, err)
+		require.NoError(t, metricsConn.ConsumeMetrics(context.Background(), testMetrics))
+
+		values := map[string]int64{}
+		for _, metrics := range metricsSink.AllMetrics() {
+			for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+				resourceMetrics := metrics.ResourceMetrics().At(i)
+				for j := 0; j < resourceMetrics.ScopeMetrics().At(0).Metrics().Len(); j++ {
+					metric := resourceMetrics.ScopeMetrics().At(0).Metrics().At(j)
+					if metric.Name() != baseCfg.CountMetricName && metric.Name() != baseCfg.BytesMetricName {
+						continue
+					}
+					for k := 0; k < metric.Sum().DataPoints().Len(); k++ {
+						dataPoint := metric.Sum().DataPoints().At(k)
+						key := fmt.Sprint(metric.Name(), resourceMetrics.Resource().Attributes().AsRaw(), dataPoint.Attributes().AsRaw())
+						values[key] = dataPoint.IntValue()
+					}
+				}
+			}
+		}
+		return values
+	}
+	expected := sums(t, baseCfg)
+	require.Len(t, expected, 12)
+
+	for name, sample := range map[string]func(cfg *Config){
+		"size_histogram":    func(cfg *Config) { cfg.SizeHistogramMetricName = "record_size" },
+		"lag_histogram":     func(cfg *Config) { cfg.LagHistogramMetricName = "record_lag" },
+		"timestamp_anomaly": func(cfg *Config) { cfg.TimestampAnomalyMetricName = "timestamp_anomalies_total" },
+		"exemplars":         func(cfg *Config) { cfg.MaxExemplars = 2 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := baseCfg
+			sample(&cfg)
+			assert.Equal(t, expected, sums(t, cfg))
+		})
+	}
+}
+
+func TestEventTimeKeepsTotals(t *testing.T) {
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_scope_logs.yaml"))
+	require.NoError(t, err)
+	testTraces, err := golden.ReadTraces(filepath.Join("testdata", "traces", "input_traces.yaml"))
+	require.NoError(t, err)
+	testMetrics, err := golden.ReadMetrics(filepath.Join("testdata", "metrics", "input_metrics.yaml"))
+	require.NoError(t, err)
+	testProfiles, err := readProfiles(filepath.Join("testdata", "profiles", "input_profiles.yaml"))
+	require.NoError(t, err)
+
+	// spread the records over the current and the previous buckets, one that has closed, and the time they are received
+	now := time.Now()
+	timestamp := func(i int) pcommon.Timestamp {
+		if i%4 == 3 {
+			return 0
+		}
+		return pcommon.NewTimestampFromTime(now.Add(-time.Duration(i%4) * 2 * time.Hour))
+	}
+	i := 0
+	for r := 0; r < testLogs.ResourceLogs().Len(); r++ {
+		scopeLogs := testLogs.ResourceLogs().At(r).ScopeLogs()
+		for s := 0; s < scopeLogs.Len(); s++ {
+			for k := 0; k < scopeLogs.At(s).LogRecords().Len(); k++ {
+				logRecord := scopeLogs.At(s).LogRecords().At(k)
+				logRecord.SetTimestamp(timestamp(i))
+				logRecord.SetObservedTimestamp(0)
+				i++
+			}
+		}
+	}
+	for r := 0; r < testTraces.ResourceSpans().Len(); r++ {
+		scopeSpans := testTraces.ResourceSpans().At(r).ScopeSpans()
+		for s := 0; s < scopeSpans.Len(); s++ {
+			for k := 0; k < scopeSpans.At(s).Spans().Len(); k++ {
+				scopeSpans.At(s).Spans().At(k).SetStartTimestamp(timestamp(i))
+				i++
+			}
+		}
+	}
+	for r := 0; r < testMetrics.ResourceMetrics().Len(); r++ {
+		scopeMetrics := testMetrics.ResourceMetrics().At(r).ScopeMetrics()
+		for s := 0; s < scopeMetrics.Len(); s++ {
+			for k := 0; k < scopeMetrics.At(s).Metrics().Len(); k++ {
+				require.NoError(t, forEachDataPoint(scopeMetrics.At(s).Metrics().At(k), func(dataPoint any, _ pcommon.Map, _ pcommon.Timestamp, _ int64, _ func() int) error {
+					dataPoint.(interface{ SetTimestamp(pcommon.Timestamp) }).SetTimestamp(timestamp(i))
+					i++
+					return nil
+				}))
+			}
+		}
+	}
+	for r := 0; r < testProfiles.ResourceProfiles().Len(); r++ {
+		scopeProfiles := testProfiles.ResourceProfiles().At(r).ScopeProfiles()
+		for s := 0; s < scopeProfiles.Len(); s++ {
+			for k := 0; k < scopeProfiles.At(s).Profiles().Len(); k++ {
+				scopeProfiles.At(s).Profiles().At(k).SetTime(timestamp(i))
+				i++
+			}
+		}
+	}
+
+	// totals returns the sums of all data points of the count and bytes metrics by metric name and component
+	totals := func(t *testing.T, cfg Config) map[string]int64 {
+		require.NoError(t, cfg.Validate())
+		metricsSink := &consumertest.MetricsSink{}
+		factory := NewFactory()
+		logsConn, err := factory.CreateLogsToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+		require.NoError(t, err)
+		require.NoError(t, logsConn.ConsumeLogs(context.Background(), testLogs))
+		require.NoError(t, logsConn.Shutdown(context.Background()))
+		tracesConn, err := factory.CreateTracesToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+		require.NoError(t, err)
+		require.NoError(t, tracesConn.ConsumeTraces(context.Background(), testTraces))
+		require.NoError(t, tracesConn.Shutdown(context.Background()))
+		metricsConn, err := factory.CreateMetricsToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+		require.NoError(t, err)
+		require.NoError(t, metricsConn.ConsumeMetrics(context.Background(), testMetrics))
+		require.NoError(t, metricsConn.Shutdown(context.Background()))
+		profilesConn, err := factory.CreateProfilesToMetrics(context.Background(), connectortest.NewNopSettings(), &cfg, metricsSink)
+		require.NoError(t, err)
+		require.NoError(t, profilesConn.ConsumeProfiles(context.Background(), testProfiles))
+		require.NoError(t, profilesConn.Shutdown(context.Background()))
+
+		values := map[string]int64{}
+		for _, metrics := range metricsSink.AllMetrics() {
+			for r := 0; r < metrics.ResourceMetrics().Len(); r++ {
+				metricSlice := metrics.ResourceMetrics().At(r).ScopeMetrics().At(0).Metrics()
+				for m := 0; m < metricSlice.Len(); m++ {
+					metric := metricSlice.At(m)
+					if metric.Type() != pmetric.MetricTypeSum {
+						continue
+					}
+					for k := 0; k < metric.Sum().DataPoints().Len(); k++ {
+						dataPoint := metric.Sum().DataPoints().At(k)
+						dataType, _ := dataPoint.Attributes().Get(dataTypeAttributeKey)
+						component, _ := dataPoint.Attributes().Get(componentAttributeKey)
+						assert.GreaterOrEqual(t, dataPoint.IntValue(), int64(0))
+						values[fmt.Sprint(dataType.Str(), metric.Name(), component.Str())] += dataPoint.IntValue()
+					}
+				}
+			}
+		}
+		return values
+	}
+
+	for _, testCase := range []struct {
+		name string
+		cfg  Config
+	}{
+		{
+			name: "resources",
+			cfg: Config{
+				CountMetricName:         "count_total",
+				BytesMetricName:         "byte_total",
+				LabelResourceAttributes: []string{"service.name"},
+			},
+		},
+		{
+			name: "scopes",
+			cfg: Config{
+				CountMetricName:         "count_total",
+				BytesMetricName:         "byte_total",
+				LabelResourceAttributes: []string{"service.name"},
+				LabelScopeName:          true,
+			},
+		},
+		{
+			name: "bytes_by_component",
+			cfg: Config{
+				BytesMetricName:  "byte_total",
+				BytesByComponent: true,
+				LabelScopeName:   true,
+			},
+		},
+		{
+			name: "count_units",
+			cfg: Config{
+				CountMetricName: "count_total",
+				Signals: SignalsConfig{
+					Traces: &SignalConfig{CountUnit: ptr(countUnitSpansEventsLinks)},
+					Metrics: &SignalConfig{
+						CountUnit: ptr(countUnitSeries),
+						Metrics:   []MetricConfig{{Name: "bucket_count_total"}},
+					},
+				},
+			},
+		},
+		{
+			name: "sampled_records",
+			cfg: Config{
+				CountMetricName:         "count_total",
+				BytesMetricName:         "byte_total",
+				SizeHistogramMetricName: "record_size",
+				MaxExemplars:            2,
+			},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			cfg := testCase.cfg
+			cfg.Output.Labels = outputLabelsAttributes
+			expected := totals(t, cfg)
+			require.NotEmpty(t, expected)
+			cfg.EventTime = EventTimeConfig{Interval: time.Hour, AllowedLateness: 3 * time.Hour}
+			assert.Equal(t, expected, totals(t, cfg))
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package datavolumeconnector
+
+import (
+	"context"
+	"fmt"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlresource"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlscope"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+)
+
+const (
+	dimensionContextResource  = "resource"
+	dimensionContextScope     = "scope"
+	dimensionContextLog       = "log"
+	dimensionContextSpan      = "span"
+	dimensionContextDataPoint = "datapoint"
+)
+
+// DimensionConfig defines an output label whose value is computed by an OTTL value expression.
+type DimensionConfig struct {
+	// The name of the output label.
+	Name string `mapstructure:"name"`
+	// The OTTL value expression computing the label value.
+	Value string `mapstructure:"value"`
+	// OTTL value expressions that are tried in order when the value evaluates to nil. The label is left out when all of them evaluate to nil.
+	Fallbacks []string `mapstructure:"fallbacks"`
+	// The OTTL context the expressions are evaluated in. resource (the default) evaluates them once per resource. scope evaluates them once per instrumentation scope and makes the connector measure scopes one by one. log, span and datapoint evaluate them for every log record, span or data point, make the connector measure those records one by one, and are ignored for the other signals.
+	Context string `mapstructure:"context"`
+}
+
+// dimension is a parsed DimensionConfig for the OTTL context K.
+type dimension[K any] struct {
+	name        string
+	expressions []string
+	values      []ottl.Getter[K]
+}
+
+// schemaURLItem matches the schema URL holders the OTTL resource context is created with.
+type schemaURLItem interface {
+	SchemaUrl() string
+	SetSchemaUrl(string)
+}
+
+type parserFunc[K any] func(functions map[string]ottl.Factory[K], set component.TelemetrySettings) (ottl.Parser[K], error)
+
+// valueFunctionName is the editor that value expressions are wrapped in, see parseValueExpression.
+const valueFunctionName = "datavolume_dimension"
+
+type valueArguments[K any] struct {
+	Value ottl.Getter[K]
+}
+
+// parseValueExpression parses a standalone OTTL value expression. This version of OTTL only parses statements and
+// conditions, so the expression is parsed as the argument of an editor whose factory captures the resulting getter.
+func parseValueExpression[K any](newParser parserFunc[K], expression string, set component.TelemetrySettings) (ottl.Getter[K], error) {
+	var getter ottl.Getter[K]
+	functions := ottlfuncs.StandardConverters[K]()
+	functions[valueFunctionName] = ottl.NewFactory(valueFunctionName, &valueArguments[K]{},
+		func(_ ottl.FunctionContext, args ottl.Arguments) (ottl.ExprFunc[K], error) {
+			arguments, ok := args.(*valueArguments[K])
+			if !ok {
+				return nil, fmt.Errorf("%s args must be of type *valueArguments", valueFunctionName)
+			}
+			getter = arguments.Value
+			return func(context.Context, K) (any, error) {
+				return nil, nil
+			}, nil
+		})
+
+	parser, err := newParser(functions, set)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = parser.ParseStatement(fmt.Sprintf("%s(%s)", valueFunctionName, expression)); err != nil {
+		return nil, err
+	}
+	return getter, nil
+}
+
+func newDimensions[K any](configs []DimensionConfig, dimensionContext string, newParser parserFunc[K], set component.TelemetrySettings) ([]dimension[K], error) {
+	var dimensions []dimension[K]
+	for _, config := range configs {
+		if config.context() != dimensionContext {
+			continue
+		}
+		parsed := dimension[K]{name: config.Name}
+		for _, expression := range append([]string{config.Value}, config.Fallbacks...) {
+			value, err := parseValueExpression(newParser, expression, set)
+			if err != nil {
+				return nil, fmt.Errorf("dimension %q: %w", config.Name, err)
+			}
+			parsed.expressions = append(parsed.expressions, expression)
+			parsed.values = append(parsed.values, value)
+		}
+		dimensions = append(dimensions, parsed)
+	}
+	return dimensions, nil
+}
+
+// newResourceDimensions returns the resource dimensions, starting with the plain keys of label_resource_attributes.
+func newResourceDimensions(cfg *Config, set component.TelemetrySettings) ([]dimension[ottlresource.TransformContext], error) {
+	keys, _ := splitAttributeKeys(cfg.LabelResourceAttributes)
+	dimensions := make([]dimension[ottlresource.TransformContext], 0, len(keys)+len(cfg.Dimensions))
+	for _, key := range keys {
+		dimensions = append(dimensions, newAttributeDimension(key, func(tCtx ottlresource.TransformContext) pcommon.Map {
+			return tCtx.GetResource().Attributes()
+		}))
+	}
+	parsed, err := newDimensions(cfg.Dimensions, dimensionContextResource, func(functions map[string]ottl.Factory[ottlresource.TransformContext], set component.TelemetrySettings) (ottl.Parser[ottlresource.TransformContext], error) {
+		return ottlresource.NewParser(functions, set)
+	}, set)
+	return append(dimensions, parsed...), err
+}
+
+// newScopeDimensions returns the scope dimensions, starting with the scope name, version and plain attribute keys to
+// label with.
+func newScopeDimensions(cfg *Config, set component.TelemetrySettings) ([]dimension[ottlscope.TransformContext], error) {
+	newParser := func(functions map[string]ottl.Factory[ottlscope.TransformContext], set component.TelemetrySettings) (ottl.Parser[ottlscope.TransformContext], error) {
+		return ottlscope.NewParser(functions, set)
+	}
+	var configs []DimensionConfig
+	if cfg.LabelScopeName {
+		configs = append(configs, DimensionConfig{Name: scopeNameAttributeKey, Value: "name", Context: dimensionContextScope})
+	}
+	if cfg.LabelScopeVersion {
+		configs = append(configs, DimensionConfig{Name: scopeVersionAttributeKey, Value: "version", Context: dimensionContextScope})
+	}
+	dimensions, err := newDimensions(configs, dimensionContextScope, newParser, set)
+	if err != nil {
+		return nil, err
+	}
+	keys, _ := splitAttributeKeys(cfg.LabelScopeAttributes)
+	for _, key := range keys {
+		dimensions = append(dimensions, newAttributeDimension(key, func(tCtx ottlscope.TransformContext) pcommon.Map {
+			return tCtx.GetInstrumentationScope().Attributes()
+		}))
+	}
+	parsed, err := newDimensions(cfg.Dimensions, dimensionContextScope, newParser, set)
+	return append(dimensions, parsed...), err
+}
+
+// newAttributeDimension returns a dimension reading the label key from the attributes of its context, see
+// lookupAttribute.
+func newAttributeDimension[K any](key string, attributes func(K) pcommon.Map) dimension[K] {
+	return dimension[K]{
+		name:        key,
+		expressions: []string{key},
+		values:      []ottl.Getter[K]{attributeGetter[K]{key: key, attributes: attributes}},
+	}
+}
+
+// newFieldDimension returns a dimension reading the label value from a field of the record of its context.
+func newFieldDimension[K any](name string, field func(K) any) dimension[K] {
+	return dimension[K]{
+		name:        name,
+		expressions: []string{name},
+		values:      []ottl.Getter[K]{fieldGetter[K](field)},
+	}
+}
+
+type fieldGetter[K any] func(K) any
+
+func (g fieldGetter[K]) Get(_ context.Context, tCtx K) (any, error) {
+	return g(tCtx), nil
+}
+
+type attributeGetter[K any] struct {
+	key        string
+	attributes func(K) pcommon.Map
+}
+
+func (g attributeGetter[K]) Get(_ context.Context, tCtx K) (any, error) {
+	if value, ok := lookupAttribute(g.attributes(tCtx), g.key); ok {
+		return value, nil
+	}
+	return nil, nil
+}
+
+// newLogDimensions returns the log record dimensions, starting with the severity number and text to label with.
+func newLogDimensions(cfg *Config, set component.TelemetrySettings) ([]dimension[ottllog.TransformContext], error) {
+	var dimensions []dimension[ottllog.TransformContext]
+	if cfg.LabelSeverityNumber {
+		dimensions = append(dimensions, newFieldDimension(severityNumberAttributeKey, func(tCtx ottllog.TransformContext) any {
+			return int64(tCtx.GetLogRecord().SeverityNumber())
+		}))
+	}
+	if cfg.LabelSeverityText {
+		dimensions = append(dimensions, newFieldDimension(severityTextAttributeKey, func(tCtx ottllog.TransformContext) any {
+			return tCtx.GetLogRecord().SeverityText()
+		}))
+	}
+	parsed, err := newDimensions(cfg.Dimensions, dimensionContextLog, func(functions map[string]ottl.Factory[ottllog.TransformContext], set component.TelemetrySettings) (ottl.Parser[ottllog.TransformContext], error) {
+		return ottllog.NewParser(functions, set)
+	}, set)
+	return append(dimensions, parsed...), err
+}
+
+// newSpanDimensions returns the span dimensions, starting with the span kind and status code to label with.
+func newSpanDimensions(cfg *Config, set component.TelemetrySettings) ([]dimension[ottlspan.TransformContext], error) {
+	var dimensions []dimension[ottlspan.TransformContext]
+	if cfg.LabelSpanKind {
+		dimensions = append(dimensions, newFieldDimension(spanKindAttributeKey, func(tCtx ottlspan.TransformContext) any {
+			return tCtx.GetSpan().Kind().String()
+		}))
+	}
+	if cfg.LabelSpanStatusCode {
+		dimensions = append(dimensions, newFieldDimension(spanStatusCodeAttributeKey, func(tCtx ottlspan.TransformContext) any {
+			return tCtx.GetSpan().Status().Code().String()
+		}))
+	}
+	parsed, err := newDimensions(cfg.Dimensions, dimensionContextSpan, func(functions map[string]ottl.Factory[ottlspan.TransformContext], set component.TelemetrySettings) (ottl.Parser[ottlspan.TransformContext], error) {
+		return ottlspan.NewParser(functions, set)
+	}, set)
+	return append(dimensions, parsed...), err
+}
+
+// newDataPointDimensions returns the data point dimensions, starting with the metric name and type to label with.
+func newDataPointDimensions(cfg *Config, set component.TelemetrySettings) ([]dimension[ottldatapoint.TransformContext], error) {
+	var dimensions []dimension[ottldatapoint.TransformContext]
+	if cfg.LabelMetricName {
+		dimensions = append(dimensions, newFieldDimension(metricNameAttributeKey, func(tCtx ottldatapoint.TransformContext) any {
+			return tCtx.GetMetric().Name()
+		}))
+	}
+	if cfg.LabelMetricType {
+		dimensions = append(dimensions, newFieldDimension(metricTypeAttributeKey, func(tCtx ottldatapoint.TransformContext) any {
+			return tCtx.GetMetric().Type().String()
+		}))
+	}
+	parsed, err := newDimensions(cfg.Dimensions, dimensionContextDataPoint, func(functions map[string]ottl.Factory[ottldatapoint.TransformContext], set component.TelemetrySettings) (ottl.Parser[ottldatapoint.TransformContext], error) {
+		return ottldatapoint.NewParser(functions, set)
+	}, set)
+	return append(dimensions, parsed...), err
+}
+
+// putDimensions evaluates the dimensions and puts the first non-nil value of each onto the attributes. Evaluation
+// errors are handled according to the error mode.
+func putDimensions[K any](ctx context.Context, dimensions []dimension[K], tCtx K, attributes pcommon.Map, errorMode ottl.ErrorMode, logger *zap.Logger) error {
+	for _, dimension := range dimensions {
+		for i, getter := range dimension.values {
+			value, err := getter.Get(ctx, tCtx)
+			if err != nil {
+				if errorMode == ottl.PropagateError {
+					return fmt.Errorf("failed to evaluate dimension %q: %w", dimension.name, err)
+				}
+				if errorMode == ottl.IgnoreError {
+					logger.Warn("failed to evaluate dimension", zap.Error(err), zap.String("dimension", dimension.name), zap.String("value", dimension.expressions[i]))
+				}
+				continue
+			}
+			if value != nil {
+				putValue(attributes, dimension.name, value)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// putValue puts a value returned by an OTTL getter onto the attributes.
+func putValue(attributes pcommon.Map, key string, value any) {
+	switch v := value.(type) {
+	case pcommon.Value:
+		v.CopyTo(attributes.PutEmpty(key))
+	case pcommon.Map:
+		v.CopyTo(attributes.PutEmptyMap(key))
+	case pcommon.Slice:
+		v.CopyTo(attributes.PutEmptySlice(key))
+	default:
+		if err := attributes.PutEmpty(key).FromRaw(v); err != nil {
+			attributes.PutStr(key, fmt.Sprint(v))
+		}
+	}
+}
+
+func (d DimensionConfig) context() string {
+	if d.Context == "" {
+		return dimensionContextResource
+	}
+	return d.Context
+}
+
+// recordContext reports whether the dimension is evaluated in the context of a log record, span or data point.
+func (d DimensionConfig) recordContext() bool {
+	switch d.context() {
+	case dimensionContextLog, dimensionContextSpan, dimensionContextDataPoint:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,405 @@
+package datavolumeconnector
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pprofile"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	logTimestampTimestamp = "timestamp"
+	logTimestampObserved  = "observed_timestamp"
+	lateAttributeKey      = "otel.event_time.late"
+)
+
+// EventTimeConfig places measurements into fixed buckets of the time records were produced, instead of stamping them
+// with the time they are received.
+type EventTimeConfig struct {
+	// The width of the buckets, which are aligned to the wall clock, so that 1m buckets start at full minutes. Measurements are stamped with the time they are received if this is not present.
+	Interval time.Duration `mapstructure:"interval"`
+	// How long after the end of a bucket records are still counted into it. A bucket is emitted once its end plus the allowed lateness has passed, and records that arrive later are counted into the bucket of their arrival, in a separate series labeled otel.event_time.late="true".
+	AllowedLateness time.Duration `mapstructure:"allowed_lateness"`
+	// The timestamp of log records that places them into buckets, timestamp (the default) or observed_timestamp. Either one falls back to the other when it is not set.
+	LogTimestamp string `mapstructure:"log_timestamp"`
+}
+
+func (c EventTimeConfig) enabled() bool {
+	return c.Interval > 0
+}
+
+// bucketStart returns the start of the bucket the timestamp falls into.
+func (c EventTimeConfig) bucketStart(timestamp pcommon.Timestamp) pcommon.Timestamp {
+	return timestamp - timestamp%pcommon.Timestamp(c.Interval)
+}
+
+// logTime returns the timestamp of a log record that places it into a bucket.
+func (c EventTimeConfig) logTime(record plog.LogRecord) pcommon.Timestamp {
+	first, second := record.Timestamp(), record.ObservedTimestamp()
+	if c.LogTimestamp == logTimestampObserved {
+		first, second = second, first
+	}
+	if first != 0 {
+		return first
+	}
+	return second
+}
+
+// eventTimeBucket holds the measurements of a bucket that is ready to be emitted.
+type eventTimeBucket struct {
+	start   pcommon.Timestamp
+	end     pcommon.Timestamp
+	volumes []dataVolume
+}
+
+// bucketAggregator accumulates data volume measurements per event time bucket and label set until the buckets close.
+type bucketAggregator struct {
+	mu      sync.Mutex
+	config  EventTimeConfig
+	buckets map[pcommon.Timestamp]*volumeSet
+	// closed is the start of the oldest bucket that may still be emitted
+	closed pcommon.Timestamp
+}
+
+func newBucketAggregator(config EventTimeConfig) *bucketAggregator {
+	return &bucketAggregator{
+		config:  config,
+		buckets: map[pcommon.Timestamp]*volumeSet{},
+	}
+}
+
+// watermark returns the start of the oldest bucket that is still open at the given time. Older buckets have closed.
+func (a *bucketAggregator) watermark(now time.Time) pcommon.Timestamp {
+	return max(a.closed, a.config.bucketStart(pcommon.NewTimestampFromTime(now.Add(-a.config.AllowedLateness))))
+}
+
+// add puts the measurements into their buckets. Measurements of buckets that have closed are late, and are put into
+// the bucket of the time they arrive with the late label. The labeled measurements are then passed through the series
+// limiter, if any.
+func (a *bucketAggregator) add(volumes []dataVolume, now time.Time, limiter *seriesLimiter) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	watermark := a.watermark(now)
+	for i, volume := range volumes {
+		if volume.bucket >= watermark {
+			continue
+		}
+		attributes := pcommon.NewMap()
+		volume.attributes.CopyTo(attributes)
+		attributes.PutStr(lateAttributeKey, "true")
+		volumes[i].attributes = attributes
+		volumes[i].bucket = max(a.config.bucketStart(pcommon.NewTimestampFromTime(now)), watermark)
+		volumes[i].late = true
+	}
+	if limiter != nil {
+		volumes = limiter.limit(volumes, now)
+	}
+	for _, volume := range volumes {
+		set, ok := a.buckets[volume.bucket]
+		if !ok {
+			set = newVolumeSet()
+			a.buckets[volume.bucket] = set
+		}
+		set.add(volume)
+	}
+}
+
+// drain removes and returns the buckets that have closed at the given time, or all buckets if all is set, oldest first.
+func (a *bucketAggregator) drain(now time.Time, all bool) []eventTimeBucket {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	watermark := a.watermark(now)
+	var buckets []eventTimeBucket
+	for start, set := range a.buckets {
+		if !all && start >= watermark {
+			continue
+		}
+		buckets = append(buckets, eventTimeBucket{
+			start:   start,
+			end:     start + pcommon.Timestamp(a.config.Interval),
+			volumes: set.volumes(),
+		})
+		delete(a.buckets, start)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].start < buckets[j].start
+	})
+	a.closed = watermark
+	if all && len(buckets) > 0 {
+		a.closed = max(a.closed, buckets[len(buckets)-1].end)
+	}
+	return buckets
+}
+
+// bucketSpread splits the measurement of a resource or scope across the event time buckets of its records. The records
+// carry their own counts and bytes into their buckets, while the bytes of the resource or scope that hold no record,
+// such as its attributes and framing, are shared out among the buckets by the number of records in each.
+type bucketSpread struct {
+	// fallback is the bucket of the items outside of any record, such as metrics without data points
+	fallback   pcommon.Timestamp
+	components bool
+	order      []pcommon.Timestamp
+	buckets    map[pcommon.Timestamp]*bucketShare
+	records    int64
+}
+
+// bucketShare holds the records of a resource or scope in a single bucket.
+type bucketShare struct {
+	records int64
+	volume  dataVolume
+}
+
+func newBucketSpread(fallback pcommon.Timestamp, components bool) *bucketSpread {
+	return &bucketSpread{
+		fallback:   fallback,
+		components: components,
+		buckets:    map[pcommon.Timestamp]*bucketShare{},
+	}
+}
+
+// add puts a record with the given count into its bucket.
+func (s *bucketSpread) add(bucket pcommon.Timestamp, count, profiles int64, record *sizing) {
+	share, ok := s.buckets[bucket]
+	if !ok {
+		share = &bucketShare{}
+		s.buckets[bucket] = share
+		s.order = append(s.order, bucket)
+	}
+	share.records++
+	share.volume.count += count
+	share.volume.profiles += profiles
+	share.volume.bytes += int64(record.size())
+	if s.components {
+		share.volume.components.add(record.components())
+	}
+	s.records++
+}
+
+// merge adds the records of other, a spread of another scope of the same resource.
+func (s *bucketSpread) merge(other *bucketSpread) {
+	for _, bucket := range other.order {
+		share, ok := s.buckets[bucket]
+		if !ok {
+			share = &bucketShare{}
+			s.buckets[bucket] = share
+			s.order = append(s.order, bucket)
+		}
+		share.records += other.buckets[bucket].records
+		share.volume.merge(other.buckets[bucket].volume)
+	}
+	s.records += other.records
+}
+
+// split returns the measurement of the resource or scope as one measurement per bucket, which add up to it. Its counts
+// beyond those of the records are placed in the fallback bucket, and so is all of it if it holds no records.
+func (s *bucketSpread) split(volume dataVolume, bytes, components bool) []dataVolume {
+	if s.records == 0 {
+		volume.bucket = s.fallback
+		return []dataVolume{volume}
+	}
+	envelope := volume
+	volumes := make([]dataVolume, 0, len(s.order)+1)
+	var records int64
+	for _, bucket := range s.order {
+		share := s.buckets[bucket]
+		envelope.count -= share.volume.count
+		envelope.profiles -= share.volume.profiles
+		envelope.bytes -= share.volume.bytes
+		for i := range envelope.components {
+			envelope.components[i] -= share.volume.components[i]
+		}
+	}
+	for _, bucket := range s.order {
+		share := s.buckets[bucket]
+		part := dataVolume{
+			attributes: volume.attributes,
+			count:      share.volume.count,
+			profiles:   share.volume.profiles,
+			bucket:     bucket,
+		}
+		if bytes {
+			part.bytes = share.volume.bytes + shareOf(envelope.bytes, records, share.records, s.records)
+		}
+		if components {
+			for i := range part.components {
+				part.components[i] = share.volume.components[i] + shareOf(envelope.components[i], records, share.records, s.records)
+			}
+			// rounding the shares of the components apart may leave them a byte or two off the bytes of the bucket
+			part.components = part.components.withOverhead(int(part.bytes))
+		}
+		records += share.records
+		volumes = append(volumes, part)
+	}
+	if envelope.count != 0 || envelope.profiles != 0 {
+		volumes = append(volumes, dataVolume{
+			attributes: volume.attributes,
+			count:      envelope.count,
+			profiles:   envelope.profiles,
+			bucket:     s.fallback,
+		})
+	}
+	return volumes
+}
+
+// shareOf returns the part of value that falls to the records from offset up to offset+records of total records. The
+// parts of all records add up to value.
+func shareOf(value, offset, records, total int64) int64 {
+	return value*(offset+records)/total - value*offset/total
+}
+
+// bucketSpreads holds the spread of a resource and of each of its scopes. It is nil when no view spreads the
+// measurements of resources or scopes across event time buckets.
+type bucketSpreads struct {
+	resource *bucketSpread
+	scopes   []*bucketSpread
+}
+
+// newBucketSpreads returns the spreads of a resource with the given number of scopes, or nil if no view needs them.
+// The scopes are spread by the caller and then merged into the spread of the resource.
+func (c *connectorImp) newBucketSpreads(b *batch, scopes int) *bucketSpreads {
+	if !c.spreadBuckets() {
+		return nil
+	}
+	fallback := c.bucket(b, 0)
+	components := c.config.BytesMetricName != "" && c.config.BytesByComponent
+	spreads := &bucketSpreads{
+		resource: newBucketSpread(fallback, components),
+		scopes:   make([]*bucketSpread, scopes),
+	}
+	for j := range spreads.scopes {
+		spreads.scopes[j] = newBucketSpread(fallback, components)
+	}
+	return spreads
+}
+
+// spreadBuckets reports whether any view measures resources or scopes as a whole while bucketing by event time.
+func (c *connectorImp) spreadBuckets() bool {
+	if !c.config.EventTime.enabled() {
+		return false
+	}
+	for _, view := range c.views {
+		if !view.measureRecords() {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeScopes merges the spreads of the scopes into that of the resource.
+func (s *bucketSpreads) mergeScopes() {
+	for _, scope := range s.scopes {
+		s.resource.merge(scope)
+	}
+}
+
+func (s *bucketSpreads) scope(j int) *bucketSpread {
+	if s == nil {
+		return nil
+	}
+	return s.scopes[j]
+}
+
+func (s *bucketSpreads) all() *bucketSpread {
+	if s == nil {
+		return nil
+	}
+	return s.resource
+}
+
+// spreadResourceLogs spreads a resource and its scopes across the event time buckets of their log records.
+func (c *connectorImp) spreadResourceLogs(b *batch, resourceLogs plog.ResourceLogs) *bucketSpreads {
+	spreads := c.newBucketSpreads(b, resourceLogs.ScopeLogs().Len())
+	if spreads == nil {
+		return nil
+	}
+	for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
+		logRecords := resourceLogs.ScopeLogs().At(j).LogRecords()
+		for k := 0; k < logRecords.Len(); k++ {
+			logRecord := logRecords.At(k)
+			recordSizing := newSizing(func() int { return logRecordSize(logRecord) }, func() byteComponents { return logRecordComponents(logRecord) })
+			spreads.scopes[j].add(c.bucket(b, c.config.EventTime.logTime(logRecord)), 1, 0, recordSizing)
+		}
+	}
+	spreads.mergeScopes()
+	return spreads
+}
+
+// spreadResourceSpans spreads a resource and its scopes across the event time buckets of their spans.
+func (c *connectorImp) spreadResourceSpans(b *batch, resourceSpans ptrace.ResourceSpans) *bucketSpreads {
+	spreads := c.newBucketSpreads(b, resourceSpans.ScopeSpans().Len())
+	if spreads == nil {
+		return nil
+	}
+	for j := 0; j < resourceSpans.ScopeSpans().Len(); j++ {
+		spans := resourceSpans.ScopeSpans().At(j).Spans()
+		for k := 0; k < spans.Len(); k++ {
+			span := spans.At(k)
+			recordSizing := newSizing(func() int { return spanSize(span) }, func() byteComponents { return spanComponents(span) })
+			spreads.scopes[j].add(c.bucket(b, span.StartTimestamp()), spanCount(span, c.countUnit), 0, recordSizing)
+		}
+	}
+	spreads.mergeScopes()
+	return spreads
+}
+
+// spreadResourceMetrics spreads a resource and its scopes across the event time buckets of their data points. A metric
+// is counted in the bucket of its first data point, and a series in the bucket of the first data point of the series.
+func (c *connectorImp) spreadResourceMetrics(b *batch, resourceMetrics pmetric.ResourceMetrics) *bucketSpreads {
+	spreads := c.newBucketSpreads(b, resourceMetrics.ScopeMetrics().Len())
+	if spreads == nil {
+		return nil
+	}
+	for j := 0; j < resourceMetrics.ScopeMetrics().Len(); j++ {
+		metrics := resourceMetrics.ScopeMetrics().At(j).Metrics()
+		series := seriesSet{}
+		for k := 0; k < metrics.Len(); k++ {
+			metric := metrics.At(k)
+			first := true
+			_ = forEachDataPoint(metric, func(_ any, attributes pcommon.Map, timestamp pcommon.Timestamp, buckets int64, fieldsSize func() int) error {
+				count := int64(1)
+				switch c.countUnit {
+				case countUnitMetrics:
+					if !first {
+						count = 0
+					}
+				case countUnitSeries:
+					if !series.add(metric, attributes) {
+						count = 0
+					}
+				case countUnitDataPointsAndBuckets:
+					count += buckets
+				}
+				first = false
+				recordSizing := newSizing(func() int { return messageFieldSize(fieldsSize()) }, func() byteComponents { return dataPointComponents(attributes, fieldsSize()) })
+				spreads.scopes[j].add(c.bucket(b, timestamp), count, 0, recordSizing)
+				return nil
+			})
+		}
+	}
+	spreads.mergeScopes()
+	return spreads
+}
+
+// spreadResourceProfiles spreads a resource and its scopes across the event time buckets of their profiles.
+func (c *connectorImp) spreadResourceProfiles(b *batch, resourceProfiles pprofile.ResourceProfiles) *bucketSpreads {
+	spreads := c.newBucketSpreads(b, resourceProfiles.ScopeProfiles().Len())
+	if spreads == nil {
+		return nil
+	}
+	for j := 0; j < resourceProfiles.ScopeProfiles().Len(); j++ {
+		profiles := resourceProfiles.ScopeProfiles().At(j).Profiles()
+		for k := 0; k < profiles.Len(); k++ {
+			profile := profiles.At(k)
+			recordSizing := newSizing(func() int { return profileSize(profile) }, func() byteComponents { return profileComponents(profile) })
+			spreads.scopes[j].add(c.bucket(b, profile.Time()), int64(profile.Sample().Len()), 1, recordSizing)
+		}
+	}
+	spreads.mergeScopes()
+	return spreads
+}
@@ -0,0 +1,131 @@
+package datavolumeconnector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func newBucketVolume(service string, bucket time.Time, bytes int64) dataVolume {
+	volume := newLimiterVolume(service, "pod-1", bytes)
+	volume.bucket = pcommon.NewTimestampFromTime(bucket)
+	return volume
+}
+
+func TestBucketAggregator(t *testing.T) {
+	aggregator := newBucketAggregator(EventTimeConfig{Interval: time.Minute, AllowedLateness: 2 * time.Minute})
+	start := time.Unix(600, 0)
+
+	aggregator.add([]dataVolume{
+		newBucketVolume("serviceA", start, 10),
+		newBucketVolume("serviceA", start, 20),
+		newBucketVolume("serviceB", start.Add(time.Minute), 30),
+	}, start.Add(90*time.Second), nil)
+
+	// the first bucket stays open until its end plus the allowed lateness has passed
+	assert.Empty(t, aggregator.drain(start.Add(2*time.Minute), false))
+	buckets := aggregator.drain(start.Add(3*time.Minute), false)
+	require.Len(t, buckets, 1)
+	assert.Equal(t, pcommon.NewTimestampFromTime(start), buckets[0].start)
+	assert.Equal(t, pcommon.NewTimestampFromTime(start.Add(time.Minute)), buckets[0].end)
+	require.Len(t, buckets[0].volumes, 1)
+	assert.Equal(t, int64(2), buckets[0].volumes[0].count)
+	assert.Equal(t, int64(30), buckets[0].volumes[0].bytes)
+
+	// records of the emitted bucket are late, and are counted into the bucket they arrive in
+	aggregator.add([]dataVolume{newBucketVolume("serviceA", start, 40)}, start.Add(3*time.Minute+time.Second), nil)
+	buckets = aggregator.drain(start.Add(time.Hour), true)
+	require.Len(t, buckets, 2)
+	assert.Equal(t, pcommon.NewTimestampFromTime(start.Add(time.Minute)), buckets[0].start)
+	assert.Equal(t, pcommon.NewTimestampFromTime(start.Add(3*time.Minute)), buckets[1].start)
+	require.Len(t, buckets[1].volumes, 1)
+	assert.Equal(t, map[string]any{
+		"service.name":   "serviceA",
+		"k8s.pod.name":   "pod-1",
+		lateAttributeKey: "true",
+	}, buckets[1].volumes[0].attributes.AsRaw())
+	assert.Equal(t, int64(40), buckets[1].volumes[0].bytes)
+}
+
+func TestBucketAggregatorDrainAll(t *testing.T) {
+	aggregator := newBucketAggregator(EventTimeConfig{Interval: time.Minute})
+	start := time.Unix(600, 0)
+
+	aggregator.add([]dataVolume{
+		newBucketVolume("serviceA", start.Add(2*time.Minute), 10),
+		newBucketVolume("serviceA", start, 20),
+	}, start, nil)
+	buckets := aggregator.drain(start, true)
+	require.Len(t, buckets, 2)
+	assert.Less(t, buckets[0].start, buckets[1].start)
+
+	// once everything is drained, no bucket can be emitted twice
+	aggregator.add([]dataVolume{newBucketVolume("serviceA", start.Add(2*time.Minute), 30)}, start, nil)
+	buckets = aggregator.drain(start, true)
+	require.Len(t, buckets, 1)
+	assert.Equal(t, pcommon.NewTimestampFromTime(start.Add(3*time.Minute)), buckets[0].start)
+	assert.Contains(t, buckets[0].volumes[0].attributes.AsRaw(), lateAttributeKey)
+}
+
+func TestBucketAggregatorLimitLate(t *testing.T) {
+	aggregator := newBucketAggregator(EventTimeConfig{Interval: time.Minute})
+	limiter := newSeriesLimiter(1, nil, 0, zap.NewNop())
+	start := time.Unix(600, 0)
+
+	aggregator.add([]dataVolume{newBucketVolume("serviceA", start, 10)}, start, limiter)
+	aggregator.drain(start.Add(2*time.Minute), false)
+
+	// the late series of serviceA is a label set of its own, which the limit folds into a late overflow series
+	aggregator.add([]dataVolume{
+		newBucketVolume("serviceA", start, 20),
+		newBucketVolume("serviceA", start.Add(2*time.Minute), 30),
+	}, start.Add(2*time.Minute), limiter)
+	buckets := aggregator.drain(start.Add(time.Hour), true)
+	require.Len(t, buckets, 1)
+	require.Len(t, buckets[0].volumes, 2)
+	assert.False(t, buckets[0].volumes[0].overflow)
+	assert.Equal(t, int64(30), buckets[0].volumes[0].bytes)
+	assert.True(t, buckets[0].volumes[1].overflow)
+	assert.Equal(t, map[string]any{overflowAttributeKey: "true", lateAttributeKey: "true"}, buckets[0].volumes[1].attributes.AsRaw())
+	assert.Equal(t, int64(20), buckets[0].volumes[1].bytes)
+}
+
+func TestEventTimeLogTime(t *testing.T) {
+	record := plog.NewLogRecord()
+	record.SetObservedTimestamp(20)
+
+	config := EventTimeConfig{Interval: time.Minute}
+	assert.Equal(t, pcommon.Timestamp(20), config.logTime(record))
+	record.SetTimestamp(10)
+	assert.Equal(t, pcommon.Timestamp(10), config.logTime(record))
+
+	config.LogTimestamp = logTimestampObserved
+	assert.Equal(t, pcommon.Timestamp(20), config.logTime(record))
+	record.SetObservedTimestamp(0)
+	assert.Equal(t, pcommon.Timestamp(10), config.logTime(record))
+}
+
+func TestBucketSpread(t *testing.T) {
+	recordSizing := func(size int) *sizing {
+		return newSizing(func() int { return size }, func() byteComponents { return byteComponents{} })
+	}
+	spread := newBucketSpread(30, false)
+	spread.add(10, 1, 0, recordSizing(5))
+	spread.add(20, 1, 0, recordSizing(7))
+	spread.add(10, 1, 0, recordSizing(6))
+
+	// the 10 bytes of the scope beyond its records are shared out two to one, the item outside of any record falls back
+	volumes := spread.split(dataVolume{count: 4, bytes: 28}, true, false)
+	require.Len(t, volumes, 3)
+	assert.Equal(t, []pcommon.Timestamp{10, 20, 30}, []pcommon.Timestamp{volumes[0].bucket, volumes[1].bucket, volumes[2].bucket})
+	assert.Equal(t, []int64{2, 1, 1}, []int64{volumes[0].count, volumes[1].count, volumes[2].count})
+	assert.Equal(t, []int64{11 + 6, 7 + 4, 0}, []int64{volumes[0].bytes, volumes[1].bytes, volumes[2].bytes})
+
+	// a scope without records is placed in the fallback bucket as a whole
+	volumes = newBucketSpread(30, false).split(dataVolume{count: 1, bytes: 9}, true, false)
+	assert.Equal(t, []dataVolume{{count: 1, bytes: 9, bucket: 30}}, volumes)
+}
@@ -0,0 +1,55 @@
+package datavolumeconnector
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// exemplar is a record that can be linked to from the measurement of its label set.
+type exemplar struct {
+	traceID   pcommon.TraceID
+	spanID    pcommon.SpanID
+	size      int
+	timestamp pcommon.Timestamp
+}
+
+// exemplarSet holds the largest records of a measurement that carry a trace ID, largest first, and at most max of them.
+type exemplarSet struct {
+	max     int
+	records []exemplar
+}
+
+// add keeps the record if it is among the largest. Records of the same size keep the order they were added in. The
+// records are never changed in place, as copies of a measurement share them.
+func (s *exemplarSet) add(record exemplar) {
+	i := 0
+	for i < len(s.records) && s.records[i].size >= record.size {
+		i++
+	}
+	if i >= s.max {
+		return
+	}
+	records := append(append(s.records[:i:i], record), s.records[i:]...)
+	s.records = records[:min(len(records), s.max)]
+}
+
+// merge keeps the largest records of both sets.
+func (s *exemplarSet) merge(other exemplarSet) {
+	if s.max == 0 {
+		s.max = other.max
+	}
+	for _, record := range other.records {
+		s.add(record)
+	}
+}
+
+// copyTo adds the records as exemplars to a data point, with their size as the value.
+func (s exemplarSet) copyTo(exemplars pmetric.ExemplarSlice) {
+	for _, record := range s.records {
+		exemplar := exemplars.AppendEmpty()
+		exemplar.SetTraceID(record.traceID)
+		exemplar.SetSpanID(record.spanID)
+		exemplar.SetTimestamp(record.timestamp)
+		exemplar.SetIntValue(int64(record.size))
+	}
+}
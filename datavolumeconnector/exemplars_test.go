@@ -0,0 +1,51 @@
+package datavolumeconnector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func exemplarSizes(set exemplarSet) []int {
+	var sizes []int
+	for _, record := range set.records {
+		sizes = append(sizes, record.size)
+	}
+	return sizes
+}
+
+func TestExemplarSet(t *testing.T) {
+	set := exemplarSet{max: 3}
+	for _, size := range []int{10, 30, 20, 5, 30, 40} {
+		set.add(exemplar{size: size, timestamp: 1})
+	}
+	assert.Equal(t, []int{40, 30, 30}, exemplarSizes(set))
+
+	// records of the same size keep the order they were added in
+	set = exemplarSet{max: 2}
+	set.add(exemplar{size: 10, timestamp: 1})
+	set.add(exemplar{size: 10, timestamp: 2})
+	set.add(exemplar{size: 10, timestamp: 3})
+	assert.Len(t, set.records, 2)
+	assert.Equal(t, []int{10, 10}, exemplarSizes(set))
+	assert.EqualValues(t, 1, set.records[0].timestamp)
+	assert.EqualValues(t, 2, set.records[1].timestamp)
+}
+
+func TestExemplarSetMerge(t *testing.T) {
+	first := exemplarSet{max: 2}
+	first.add(exemplar{size: 10})
+	first.add(exemplar{size: 30})
+	// a copy of the measurement shares the records, which merging must leave untouched
+	shared := first
+
+	var merged exemplarSet
+	merged.merge(first)
+	second := exemplarSet{max: 2}
+	second.add(exemplar{size: 20})
+	merged.merge(second)
+	assert.Equal(t, []int{30, 20}, exemplarSizes(merged))
+
+	first.merge(second)
+	assert.Equal(t, []int{30, 20}, exemplarSizes(first))
+	assert.Equal(t, []int{30, 10}, exemplarSizes(shared))
+}
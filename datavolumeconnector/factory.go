@@ -2,53 +2,117 @@ package datavolumeconnector
 
 import (
 	"context"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/connector/xconnector"
 	"go.opentelemetry.io/collector/consumer"
+	"slices"
+	"time"
 )
 
 var typeStr = component.MustNewType("datavolume")
 
+const defaultSeriesExpiration = time.Hour
+
 func createDefaultConfig() component.Config {
 	return &Config{
 		CountMetricName:         "",
 		BytesMetricName:         "",
 		LabelResourceAttributes: make([]string, 0),
+		LabelRecordAttributes:   make([]string, 0),
+		LabelScopeAttributes:    make([]string, 0),
+		MaxMatchedAttributes:    defaultMaxMatchedAttributes,
+		Temporality:             temporalityDelta,
+		SeriesExpiration:        defaultSeriesExpiration,
+		ParallelismThreshold:    defaultParallelismThreshold,
+		MaxClockSkew:            defaultMaxClockSkew,
+		MaxOutOfOrder:           defaultMaxOutOfOrder,
+		ErrorMode:               ottl.PropagateError,
 	}
 }
 
-func NewFactory() connector.Factory {
-	return connector.NewFactory(
+func NewFactory() xconnector.Factory {
+	return xconnector.NewFactory(
 		typeStr,
 		createDefaultConfig,
-		connector.WithTracesToMetrics(createTracesToMetricsConnector, component.StabilityLevelDevelopment),
-		connector.WithLogsToMetrics(createLogsToMetricsConnector, component.StabilityLevelDevelopment),
-		connector.WithMetricsToMetrics(createMetricsToMetricsConnector, component.StabilityLevelDevelopment))
+		xconnector.WithTracesToMetrics(createTracesToMetricsConnector, component.StabilityLevelDevelopment),
+		xconnector.WithLogsToMetrics(createLogsToMetricsConnector, component.StabilityLevelDevelopment),
+		xconnector.WithMetricsToMetrics(createMetricsToMetricsConnector, component.StabilityLevelDevelopment),
+		xconnector.WithProfilesToMetrics(createProfilesToMetricsConnector, component.StabilityLevelDevelopment))
 }
 
 func createLogsToMetricsConnector(ctx context.Context, params connector.Settings, cfg component.Config, nextConsumer consumer.Metrics) (connector.Logs, error) {
-	c, err := newConnector(params.Logger, cfg)
+	c, err := newConnector(params, cfg, dataTypeLogsAttributeValue)
 	if err != nil {
 		return nil, err
 	}
+	for _, view := range c.views {
+		view.logConditions, err = newLogConditions(view.config.Conditions.Logs, view.errorMode, params.TelemetrySettings)
+		if err != nil {
+			return nil, err
+		}
+		view.logDimensions, err = newLogDimensions(&view.config, params.TelemetrySettings)
+		if err != nil {
+			return nil, err
+		}
+	}
 	c.metricsConsumer = nextConsumer
 	return c, nil
 }
 
 func createMetricsToMetricsConnector(ctx context.Context, params connector.Settings, cfg component.Config, nextConsumer consumer.Metrics) (connector.Metrics, error) {
-	c, err := newConnector(params.Logger, cfg)
+	c, err := newConnector(params, cfg, dataTypeMetricsAttributeValue)
 	if err != nil {
 		return nil, err
 	}
+	for _, view := range c.views {
+		view.dataPointConditions, err = newDataPointConditions(view.config.Conditions.Metrics, view.errorMode, params.TelemetrySettings)
+		if err != nil {
+			return nil, err
+		}
+		view.dataPointDimensions, err = newDataPointDimensions(&view.config, params.TelemetrySettings)
+		if err != nil {
+			return nil, err
+		}
+	}
 	c.metricsConsumer = nextConsumer
 	return c, nil
 }
 
 func createTracesToMetricsConnector(ctx context.Context, params connector.Settings, cfg component.Config, nextConsumer consumer.Metrics) (connector.Traces, error) {
-	c, err := newConnector(params.Logger, cfg)
+	c, err := newConnector(params, cfg, dataTypeTracesAttributeValue)
+	if err != nil {
+		return nil, err
+	}
+	for _, view := range c.views {
+		view.spanConditions, err = newSpanConditions(view.config.Conditions.Traces, view.errorMode, params.TelemetrySettings)
+		if err != nil {
+			return nil, err
+		}
+		view.spanDimensions, err = newSpanDimensions(&view.config, params.TelemetrySettings)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.metricsConsumer = nextConsumer
+	return c, nil
+}
+
+// createProfilesToMetricsConnector creates a connector for profiles, which have no OTTL context, so their records are
+// neither filtered by conditions nor labeled by record dimensions. Validate rejects them in a profiles block, shared
+// ones are ignored with a warning.
+func createProfilesToMetricsConnector(ctx context.Context, params connector.Settings, cfg component.Config, nextConsumer consumer.Metrics) (xconnector.Profiles, error) {
+	c, err := newConnector(params, cfg, dataTypeProfilesAttributeValue)
 	if err != nil {
 		return nil, err
 	}
+	for _, view := range c.views {
+		if !view.config.Conditions.empty() || slices.ContainsFunc(view.config.Dimensions, DimensionConfig.recordContext) {
+			params.Logger.Warn("datavolume conditions and record dimensions are not supported for profiles, profiles are measured without them")
+			break
+		}
+	}
 	c.metricsConsumer = nextConsumer
 	return c, nil
 }
@@ -0,0 +1,283 @@
+package datavolumeconnector
+
+import (
+	"fmt"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"math"
+	"sort"
+)
+
+const (
+	// defaultExponentialMaxSize is the default number of buckets of the exponential size histogram.
+	defaultExponentialMaxSize = 160
+	// maxExponentialScale is the finest scale exponential size histograms start at before they are downscaled.
+	maxExponentialScale = 20
+)
+
+// defaultSizeBuckets are the explicit bucket boundaries of the size histogram, in bytes, if none are configured.
+var defaultSizeBuckets = []float64{64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 65536, 262144, 1048576}
+
+// SizeHistogramConfig configures the buckets of the size or lag histogram. Explicit buckets are used if neither or only
+// explicit is present.
+type SizeHistogramConfig struct {
+	Explicit    *ExplicitHistogramConfig    `mapstructure:"explicit"`
+	Exponential *ExponentialHistogramConfig `mapstructure:"exponential"`
+}
+
+// validate checks the buckets of the histogram configured under name.
+func (c SizeHistogramConfig) validate(name string) error {
+	if c.Explicit != nil && c.Exponential != nil {
+		return fmt.Errorf("%s must not have both explicit and exponential buckets", name)
+	}
+	if c.Explicit != nil {
+		for i := 1; i < len(c.Explicit.Buckets); i++ {
+			if c.Explicit.Buckets[i] <= c.Explicit.Buckets[i-1] {
+				return fmt.Errorf("%s explicit buckets must be in increasing order", name)
+			}
+		}
+	}
+	if c.Exponential != nil && c.Exponential.MaxSize < 0 {
+		return fmt.Errorf("%s exponential max_size must not be negative", name)
+	}
+	return nil
+}
+
+type ExplicitHistogramConfig struct {
+	// The upper bounds of the buckets in bytes for the size histogram or milliseconds for the lag histogram, in increasing order. Defaults to 64 bytes doubling up to 16 KiB, then 64 KiB, 256 KiB and 1 MiB for sizes and to 100ms up to 1h for lags.
+	Buckets []float64 `mapstructure:"buckets"`
+}
+
+type ExponentialHistogramConfig struct {
+	// The maximum number of buckets per histogram. The scale is reduced until all sizes fit. Defaults to 160.
+	MaxSize int32 `mapstructure:"max_size"`
+}
+
+// sizeHistogram is the distribution of individual record sizes, or of record lags, of a label set.
+type sizeHistogram interface {
+	record(size int)
+	merge(other sizeHistogram)
+	clone() sizeHistogram
+	copyTo(metric pmetric.Metric, labels pcommon.Map, temporality pmetric.AggregationTemporality, start, timestamp pcommon.Timestamp)
+}
+
+// newSizeHistogram returns an empty histogram, with the default bounds if explicit buckets are used and none are
+// configured.
+func newSizeHistogram(cfg SizeHistogramConfig, defaultBounds []float64) sizeHistogram {
+	if cfg.Exponential != nil {
+		maxSize := cfg.Exponential.MaxSize
+		if maxSize == 0 {
+			maxSize = defaultExponentialMaxSize
+		}
+		return &exponentialHistogram{maxSize: maxSize, scale: maxExponentialScale}
+	}
+	bounds := defaultBounds
+	if cfg.Explicit != nil && len(cfg.Explicit.Buckets) > 0 {
+		bounds = cfg.Explicit.Buckets
+	}
+	return &explicitHistogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+// histogramStats are the fields shared by both histogram types.
+type histogramStats struct {
+	count uint64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+func (s *histogramStats) record(value float64) {
+	if s.count == 0 || value < s.min {
+		s.min = value
+	}
+	if s.count == 0 || value > s.max {
+		s.max = value
+	}
+	s.count++
+	s.sum += value
+}
+
+func (s *histogramStats) merge(other histogramStats) {
+	if other.count == 0 {
+		return
+	}
+	if s.count == 0 || other.min < s.min {
+		s.min = other.min
+	}
+	if s.count == 0 || other.max > s.max {
+		s.max = other.max
+	}
+	s.count += other.count
+	s.sum += other.sum
+}
+
+type explicitHistogram struct {
+	histogramStats
+	bounds []float64
+	counts []uint64
+}
+
+func (h *explicitHistogram) record(size int) {
+	value := float64(size)
+	h.histogramStats.record(value)
+	h.counts[sort.SearchFloat64s(h.bounds, value)]++
+}
+
+func (h *explicitHistogram) merge(other sizeHistogram) {
+	o := other.(*explicitHistogram)
+	h.histogramStats.merge(o.histogramStats)
+	for i, count := range o.counts {
+		h.counts[i] += count
+	}
+}
+
+func (h *explicitHistogram) clone() sizeHistogram {
+	c := *h
+	c.counts = append([]uint64(nil), h.counts...)
+	return &c
+}
+
+func (h *explicitHistogram) copyTo(metric pmetric.Metric, labels pcommon.Map, temporality pmetric.AggregationTemporality, start, timestamp pcommon.Timestamp) {
+	if metric.Type() != pmetric.MetricTypeHistogram {
+		metric.SetEmptyHistogram().SetAggregationTemporality(temporality)
+	}
+	dataPoint := metric.Histogram().DataPoints().AppendEmpty()
+	labels.CopyTo(dataPoint.Attributes())
+	dataPoint.SetStartTimestamp(start)
+	dataPoint.SetTimestamp(timestamp)
+	dataPoint.SetCount(h.count)
+	dataPoint.SetSum(h.sum)
+	if h.count > 0 {
+		dataPoint.SetMin(h.min)
+		dataPoint.SetMax(h.max)
+	}
+	dataPoint.ExplicitBounds().FromRaw(h.bounds)
+	dataPoint.BucketCounts().FromRaw(h.counts)
+}
+
+// exponentialHistogram is a base-2 exponential histogram of positive sizes. It starts at the finest scale and is
+// downscaled whenever the populated buckets would span more than maxSize buckets.
+type exponentialHistogram struct {
+	histogramStats
+	maxSize   int32
+	scale     int32
+	zeroCount uint64
+	offset    int32
+	counts    []uint64
+}
+
+func (h *exponentialHistogram) record(size int) {
+	value := float64(size)
+	h.histogramStats.record(value)
+	if size <= 0 {
+		h.zeroCount++
+		return
+	}
+	index := bucketIndex(value, h.scale)
+	if len(h.counts) == 0 {
+		h.offset = index
+		h.counts = []uint64{1}
+		return
+	}
+	low, high := min(h.offset, index), max(h.offset+int32(len(h.counts))-1, index)
+	if change := h.scaleChange(low, high); change > 0 {
+		h.downscale(change)
+		index >>= change
+	}
+	h.grow(index)
+	h.counts[index-h.offset]++
+}
+
+func (h *exponentialHistogram) merge(other sizeHistogram) {
+	o := other.(*exponentialHistogram)
+	h.histogramStats.merge(o.histogramStats)
+	h.zeroCount += o.zeroCount
+	if len(o.counts) == 0 {
+		return
+	}
+	if len(h.counts) == 0 {
+		h.scale, h.offset, h.counts = o.scale, o.offset, append([]uint64(nil), o.counts...)
+		return
+	}
+
+	if o.scale < h.scale {
+		h.downscale(h.scale - o.scale)
+	}
+	shift := o.scale - h.scale
+	otherLow, otherHigh := o.offset>>shift, (o.offset+int32(len(o.counts))-1)>>shift
+	if change := h.scaleChange(min(h.offset, otherLow), max(h.offset+int32(len(h.counts))-1, otherHigh)); change > 0 {
+		h.downscale(change)
+		shift += change
+	}
+	for i, count := range o.counts {
+		index := (o.offset + int32(i)) >> shift
+		h.grow(index)
+		h.counts[index-h.offset] += count
+	}
+}
+
+func (h *exponentialHistogram) clone() sizeHistogram {
+	c := *h
+	c.counts = append([]uint64(nil), h.counts...)
+	return &c
+}
+
+func (h *exponentialHistogram) copyTo(metric pmetric.Metric, labels pcommon.Map, temporality pmetric.AggregationTemporality, start, timestamp pcommon.Timestamp) {
+	if metric.Type() != pmetric.MetricTypeExponentialHistogram {
+		metric.SetEmptyExponentialHistogram().SetAggregationTemporality(temporality)
+	}
+	dataPoint := metric.ExponentialHistogram().DataPoints().AppendEmpty()
+	labels.CopyTo(dataPoint.Attributes())
+	dataPoint.SetStartTimestamp(start)
+	dataPoint.SetTimestamp(timestamp)
+	dataPoint.SetCount(h.count)
+	dataPoint.SetSum(h.sum)
+	if h.count > 0 {
+		dataPoint.SetMin(h.min)
+		dataPoint.SetMax(h.max)
+	}
+	dataPoint.SetScale(h.scale)
+	dataPoint.SetZeroCount(h.zeroCount)
+	dataPoint.Positive().SetOffset(h.offset)
+	dataPoint.Positive().BucketCounts().FromRaw(h.counts)
+}
+
+// scaleChange returns by how much the scale has to be reduced for the bucket indexes from low to high to fit.
+func (h *exponentialHistogram) scaleChange(low, high int32) int32 {
+	var change int32
+	for high-low+1 > h.maxSize {
+		low >>= 1
+		high >>= 1
+		change++
+	}
+	return change
+}
+
+// downscale reduces the scale by change, merging every 2^change neighbouring buckets.
+func (h *exponentialHistogram) downscale(change int32) {
+	if len(h.counts) > 0 {
+		offset := h.offset >> change
+		counts := make([]uint64, ((h.offset+int32(len(h.counts))-1)>>change)-offset+1)
+		for i, count := range h.counts {
+			counts[((h.offset+int32(i))>>change)-offset] += count
+		}
+		h.offset, h.counts = offset, counts
+	}
+	h.scale -= change
+}
+
+// grow extends the buckets so that index is covered.
+func (h *exponentialHistogram) grow(index int32) {
+	if index < h.offset {
+		h.counts = append(make([]uint64, h.offset-index), h.counts...)
+		h.offset = index
+	}
+	if last := h.offset + int32(len(h.counts)) - 1; index > last {
+		h.counts = append(h.counts, make([]uint64, index-last)...)
+	}
+}
+
+// bucketIndex returns the index of the bucket (base^index, base^(index+1)] holding value, with base 2^(2^-scale).
+func bucketIndex(value float64, scale int32) int32 {
+	return int32(math.Ceil(math.Log2(value)*math.Ldexp(1, int(scale)))) - 1
+}
@@ -0,0 +1,63 @@
+package datavolumeconnector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"math"
+	"testing"
+)
+
+var testSizes = []int{0, 1, 2, 3, 17, 64, 65, 300, 1000, 1024, 4096, 70000, 1 << 20, 5 << 20}
+
+func TestExplicitSizeHistogram(t *testing.T) {
+	histogram := newSizeHistogram(SizeHistogramConfig{Explicit: &ExplicitHistogramConfig{Buckets: []float64{64, 1024}}}, defaultSizeBuckets)
+	for _, size := range testSizes {
+		histogram.record(size)
+	}
+
+	metric := pmetric.NewMetric()
+	histogram.copyTo(metric, pcommon.NewMap(), pmetric.AggregationTemporalityDelta, 1, 2)
+	dataPoint := metric.Histogram().DataPoints().At(0)
+	assert.Equal(t, uint64(len(testSizes)), dataPoint.Count())
+	assert.Equal(t, []uint64{6, 4, 4}, dataPoint.BucketCounts().AsRaw())
+	assert.Equal(t, float64(0), dataPoint.Min())
+	assert.Equal(t, float64(5<<20), dataPoint.Max())
+}
+
+func TestExponentialSizeHistogram(t *testing.T) {
+	cfg := SizeHistogramConfig{Exponential: &ExponentialHistogramConfig{MaxSize: 8}}
+
+	recorded := newSizeHistogram(cfg, defaultSizeBuckets)
+	first, second := newSizeHistogram(cfg, defaultSizeBuckets), newSizeHistogram(cfg, defaultSizeBuckets)
+	for i, size := range testSizes {
+		recorded.record(size)
+		if i%2 == 0 {
+			first.record(size)
+		} else {
+			second.record(size)
+		}
+	}
+	first.merge(second)
+
+	for _, histogram := range []sizeHistogram{recorded, first} {
+		metric := pmetric.NewMetric()
+		histogram.copyTo(metric, pcommon.NewMap(), pmetric.AggregationTemporalityDelta, 1, 2)
+		dataPoint := metric.ExponentialHistogram().DataPoints().At(0)
+		require.LessOrEqual(t, dataPoint.Positive().BucketCounts().Len(), 8)
+		assert.Equal(t, uint64(len(testSizes)), dataPoint.Count())
+		assert.Equal(t, uint64(1), dataPoint.ZeroCount())
+
+		// every size has to be counted in the bucket whose boundaries enclose it
+		expected := make([]uint64, dataPoint.Positive().BucketCounts().Len())
+		base := math.Exp2(math.Exp2(-float64(dataPoint.Scale())))
+		for _, size := range testSizes[1:] {
+			index := int(math.Ceil(math.Log(float64(size))/math.Log(base))) - 1 - int(dataPoint.Positive().Offset())
+			require.GreaterOrEqual(t, index, 0)
+			require.Less(t, index, len(expected))
+			expected[index]++
+		}
+		assert.Equal(t, expected, dataPoint.Positive().BucketCounts().AsRaw())
+	}
+}
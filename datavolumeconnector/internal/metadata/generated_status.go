@@ -12,7 +12,8 @@ var (
 )
 
 const (
-	TracesToMetricsStability  = component.StabilityLevelDevelopment
-	MetricsToMetricsStability = component.StabilityLevelDevelopment
-	LogsToMetricsStability    = component.StabilityLevelDevelopment
+	TracesToMetricsStability   = component.StabilityLevelDevelopment
+	MetricsToMetricsStability  = component.StabilityLevelDevelopment
+	LogsToMetricsStability     = component.StabilityLevelDevelopment
+	ProfilesToMetricsStability = component.StabilityLevelDevelopment
 )
@@ -0,0 +1,246 @@
+package datavolumeconnector
+
+import (
+	"fmt"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	complexValuesJSON = "json"
+	complexValuesJoin = "join"
+	complexValuesDrop = "drop"
+)
+
+const (
+	componentIDAttributeKey       = "otelcol.component.id"
+	serviceInstanceIDAttributeKey = "service.instance.id"
+	hostNameAttributeKey          = "host.name"
+)
+
+// IdentityLabelsConfig selects labels identifying the connector instance that took the measurements.
+type IdentityLabelsConfig struct {
+	// Label output metrics with the ID of the connector as otelcol.component.id.
+	ComponentID bool `mapstructure:"component_id"`
+	// Label output metrics with the service.instance.id of the collector.
+	ServiceInstanceID bool `mapstructure:"service_instance_id"`
+	// Label output metrics with the host.name of the collector, taken from its telemetry resource or the operating system.
+	HostName bool `mapstructure:"host_name"`
+}
+
+// LabelConfig maps an output label before it is emitted. The value of the label is normalized first, in the order
+// extract, replace, lowercase and truncate, then the default is applied, and finally the label is renamed.
+type LabelConfig struct {
+	// The name of the output label to map, as produced by the label options or dimensions.
+	Key string `mapstructure:"key"`
+	// The name the label is emitted as. The label keeps its name if this is not present.
+	Rename string `mapstructure:"rename"`
+	// The value of the label when it is missing, or when its value does not match extract. The label is left out if this is not present.
+	Default string `mapstructure:"default"`
+	// A regular expression whose first capture group, or whole match if it has none, becomes the value.
+	Extract string `mapstructure:"extract"`
+	// A regular expression replacement applied to the value.
+	Replace *ReplaceConfig `mapstructure:"replace"`
+	// Convert the value to lowercase.
+	Lowercase bool `mapstructure:"lowercase"`
+	// Truncate the value to at most this many characters. Values are not truncated if this is not present.
+	Truncate int `mapstructure:"truncate"`
+}
+
+type ReplaceConfig struct {
+	// The regular expression matching the parts of the value to replace.
+	Pattern string `mapstructure:"pattern"`
+	// The replacement for each match, which may refer to capture groups as $1 or ${name}.
+	Replacement string `mapstructure:"replacement"`
+}
+
+// newConstantLabels returns the static and identity labels that every output label set of the connector starts with.
+func newConstantLabels(cfg *Config, set connector.Settings) pcommon.Map {
+	labels := pcommon.NewMap()
+	for key, value := range cfg.StaticLabels {
+		labels.PutStr(key, value)
+	}
+	if cfg.IdentityLabels.ComponentID {
+		labels.PutStr(componentIDAttributeKey, set.ID.String())
+	}
+	if cfg.IdentityLabels.ServiceInstanceID {
+		if value, ok := set.Resource.Attributes().Get(serviceInstanceIDAttributeKey); ok {
+			labels.PutStr(serviceInstanceIDAttributeKey, value.AsString())
+		}
+	}
+	if cfg.IdentityLabels.HostName {
+		if value, ok := set.Resource.Attributes().Get(hostNameAttributeKey); ok {
+			labels.PutStr(hostNameAttributeKey, value.AsString())
+		} else if hostName, err := os.Hostname(); err == nil {
+			labels.PutStr(hostNameAttributeKey, hostName)
+		}
+	}
+	return labels
+}
+
+// lookupAttribute finds the value of a label key in attributes. A key that is not an attribute itself is resolved as a
+// dotted path into map and slice values, preferring the longest attribute name, so k8s.pod.labels.team addresses the
+// team entry of the k8s.pod.labels map and k8s.containers.0.name the name of the first container.
+func lookupAttribute(attributes pcommon.Map, key string) (pcommon.Value, bool) {
+	if value, ok := attributes.Get(key); ok {
+		return value, true
+	}
+	for i := strings.LastIndexByte(key, '.'); i > 0; i = strings.LastIndexByte(key[:i], '.') {
+		value, ok := attributes.Get(key[:i])
+		if !ok {
+			continue
+		}
+		if value, ok = lookupPath(value, key[i+1:]); ok {
+			return value, true
+		}
+	}
+	return pcommon.Value{}, false
+}
+
+func lookupPath(value pcommon.Value, path string) (pcommon.Value, bool) {
+	switch value.Type() {
+	case pcommon.ValueTypeMap:
+		return lookupAttribute(value.Map(), path)
+	case pcommon.ValueTypeSlice:
+		index, rest, nested := strings.Cut(path, ".")
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= value.Slice().Len() {
+			return pcommon.Value{}, false
+		}
+		if !nested {
+			return value.Slice().At(i), true
+		}
+		return lookupPath(value.Slice().At(i), rest)
+	}
+	return pcommon.Value{}, false
+}
+
+// serializeComplexValues replaces the map and slice values of a label set, which most metric backends cannot
+// represent, according to the complex_values mode.
+func serializeComplexValues(attributes pcommon.Map, mode string) {
+	attributes.RemoveIf(func(_ string, value pcommon.Value) bool {
+		if value.Type() != pcommon.ValueTypeMap && value.Type() != pcommon.ValueTypeSlice {
+			return false
+		}
+		switch mode {
+		case complexValuesDrop:
+			return true
+		case complexValuesJoin:
+			value.SetStr(joinValue(value))
+		default:
+			value.SetStr(value.AsString())
+		}
+		return false
+	})
+}
+
+// joinValue joins the entries of a map as key=value pairs sorted by key, and the elements of a slice in order, with
+// commas.
+func joinValue(value pcommon.Value) string {
+	var parts []string
+	switch value.Type() {
+	case pcommon.ValueTypeMap:
+		value.Map().Range(func(key string, entry pcommon.Value) bool {
+			parts = append(parts, key+"="+entry.AsString())
+			return true
+		})
+		sort.Strings(parts)
+	case pcommon.ValueTypeSlice:
+		for i := 0; i < value.Slice().Len(); i++ {
+			parts = append(parts, value.Slice().At(i).AsString())
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// labelMapping is a compiled LabelConfig.
+type labelMapping struct {
+	LabelConfig
+	extract *regexp.Regexp
+	replace *regexp.Regexp
+}
+
+func newLabelMappings(configs []LabelConfig) ([]labelMapping, error) {
+	mappings := make([]labelMapping, 0, len(configs))
+	for _, config := range configs {
+		if config.Key == "" {
+			return nil, fmt.Errorf("labels must have a key")
+		}
+		if config.Truncate < 0 {
+			return nil, fmt.Errorf("label %q truncate must not be negative", config.Key)
+		}
+		mapping := labelMapping{LabelConfig: config}
+		var err error
+		if config.Extract != "" {
+			if mapping.extract, err = regexp.Compile(config.Extract); err != nil {
+				return nil, fmt.Errorf("label %q extract: %w", config.Key, err)
+			}
+		}
+		if config.Replace != nil {
+			if mapping.replace, err = regexp.Compile(config.Replace.Pattern); err != nil {
+				return nil, fmt.Errorf("label %q replace: %w", config.Key, err)
+			}
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// mapLabels applies the mappings to a final output label set.
+func mapLabels(mappings []labelMapping, attributes pcommon.Map) {
+	for _, mapping := range mappings {
+		value, ok := attributes.Get(mapping.Key)
+		if ok && mapping.normalizes() {
+			var normalized string
+			if normalized, ok = mapping.normalize(value.AsString()); ok {
+				attributes.PutStr(mapping.Key, normalized)
+			} else {
+				attributes.Remove(mapping.Key)
+			}
+		}
+		if !ok && mapping.Default != "" {
+			attributes.PutStr(mapping.Key, mapping.Default)
+		}
+		if mapping.Rename != "" && mapping.Rename != mapping.Key {
+			if value, ok := attributes.Get(mapping.Key); ok {
+				value.CopyTo(attributes.PutEmpty(mapping.Rename))
+				attributes.Remove(mapping.Key)
+			}
+		}
+	}
+}
+
+func (m labelMapping) normalizes() bool {
+	return m.extract != nil || m.replace != nil || m.Lowercase || m.Truncate > 0
+}
+
+// normalize returns the normalized value, or false if the value does not match extract.
+func (m labelMapping) normalize(value string) (string, bool) {
+	if m.extract != nil {
+		match := m.extract.FindStringSubmatch(value)
+		if match == nil {
+			return "", false
+		}
+		value = match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+	}
+	if m.replace != nil {
+		value = m.replace.ReplaceAllString(value, m.Replace.Replacement)
+	}
+	if m.Lowercase {
+		value = strings.ToLower(value)
+	}
+	if m.Truncate > 0 {
+		if runes := []rune(value); len(runes) > m.Truncate {
+			value = string(runes[:m.Truncate])
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,67 @@
+package datavolumeconnector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"testing"
+)
+
+func TestMapLabels(t *testing.T) {
+	mappings, err := newLabelMappings([]LabelConfig{
+		{Key: "service.name", Rename: "service", Default: "unknown"},
+		{Key: "k8s.pod.name", Extract: `^(.+)-[a-z0-9]{5}$`, Default: "other"},
+		{Key: "http.route", Replace: &ReplaceConfig{Pattern: `/\d+`, Replacement: "/{id}"}},
+		{Key: "env", Lowercase: true, Truncate: 4},
+		{Key: "http.status_code", Truncate: 1},
+	})
+	require.NoError(t, err)
+
+	attributes := pcommon.NewMap()
+	attributes.PutStr("k8s.pod.name", "checkout-7d9f8")
+	attributes.PutStr("http.route", "/users/42/orders/7")
+	attributes.PutStr("env", "Production")
+	attributes.PutInt("http.status_code", 503)
+	mapLabels(mappings, attributes)
+	assert.Equal(t, map[string]any{
+		"service":          "unknown",
+		"k8s.pod.name":     "checkout",
+		"http.route":       "/users/{id}/orders/{id}",
+		"env":              "prod",
+		"http.status_code": "5",
+	}, attributes.AsRaw())
+
+	// values that do not match extract fall back to the default
+	attributes = pcommon.NewMap()
+	attributes.PutStr("service.name", "checkout")
+	attributes.PutStr("k8s.pod.name", "standalone")
+	mapLabels(mappings, attributes)
+	assert.Equal(t, map[string]any{
+		"service":      "checkout",
+		"k8s.pod.name": "other",
+	}, attributes.AsRaw())
+}
+
+func TestLookupAttribute(t *testing.T) {
+	attributes := pcommon.NewMap()
+	require.NoError(t, attributes.FromRaw(map[string]any{
+		"k8s.pod.labels":      map[string]any{"team": "edge", "app.kubernetes.io/name": "checkout"},
+		"k8s.pod.labels.team": "literal",
+		"k8s.containers":      []any{map[string]any{"name": "app"}, map[string]any{"name": "sidecar"}},
+	}))
+
+	for key, expected := range map[string]any{
+		"k8s.pod.labels.team":                   "literal",
+		"k8s.pod.labels.app.kubernetes.io/name": "checkout",
+		"k8s.containers.1.name":                 "sidecar",
+		"k8s.containers.0":                      map[string]any{"name": "app"},
+	} {
+		value, ok := lookupAttribute(attributes, key)
+		require.True(t, ok, key)
+		assert.Equal(t, expected, value.AsRaw(), key)
+	}
+	for _, key := range []string{"k8s.pod.labels.owner", "k8s.containers.2.name", "k8s.containers.first", "k8s"} {
+		_, ok := lookupAttribute(attributes, key)
+		assert.False(t, ok, key)
+	}
+}
@@ -0,0 +1,174 @@
+package datavolumeconnector
+
+import (
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatautil"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	overflowAttributeKey       = "otel.metric.overflow"
+	rejectedSeriesMetricName   = "datavolume_rejected_series"
+	rejectedMetricAttributeKey = "metric"
+
+	// maxRejectedSeries bounds the memory the rejected label sets take, also when they never expire
+	maxRejectedSeries = 10000
+)
+
+// seriesLimiter caps the number of distinct label sets of a view, and optionally the number of distinct values of single
+// labels. Label sets beyond the limits are folded into a single overflow series.
+type seriesLimiter struct {
+	mu             sync.Mutex
+	maxSeries      int
+	maxLabelValues map[string]int
+	expiration     time.Duration
+	logger         *zap.Logger
+	warned         bool
+	// overflowLabels are the labels of the overflow series, the constant labels of the view and the overflow label
+	overflowLabels pcommon.Map
+
+	series      map[[16]byte]*limitedSeries
+	labelValues map[string]map[string]int
+	rejected    map[[16]byte]time.Time
+	maxRejected int
+}
+
+// limitedSeries is an admitted label set, with the values it holds of the labels whose values are limited.
+type limitedSeries struct {
+	lastSeen time.Time
+	values   map[string]string
+}
+
+func newSeriesLimiter(maxSeries int, maxLabelValues map[string]int, expiration time.Duration, logger *zap.Logger) *seriesLimiter {
+	if maxSeries == 0 && len(maxLabelValues) == 0 {
+		return nil
+	}
+	l := &seriesLimiter{
+		maxSeries:      maxSeries,
+		maxLabelValues: maxLabelValues,
+		expiration:     expiration,
+		logger:         logger,
+		overflowLabels: pcommon.NewMap(),
+		series:         map[[16]byte]*limitedSeries{},
+		labelValues:    map[string]map[string]int{},
+		rejected:       map[[16]byte]time.Time{},
+		maxRejected:    maxRejectedSeries,
+	}
+	l.overflowLabels.PutStr(overflowAttributeKey, "true")
+	for label := range maxLabelValues {
+		l.labelValues[label] = map[string]int{}
+	}
+	return l
+}
+
+// setConstantLabels sets the labels that every series of the view carries, which the overflow series keeps.
+func (l *seriesLimiter) setConstantLabels(labels pcommon.Map) {
+	labels.CopyTo(l.overflowLabels)
+	l.overflowLabels.PutStr(overflowAttributeKey, "true")
+}
+
+// limit passes on the measurements of admitted label sets and merges all others into a single overflow measurement at
+// the end, one per event time bucket and for late measurements apart, which keep their late label.
+func (l *seriesLimiter) limit(volumes []dataVolume, now time.Time) []dataVolume {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	limited := volumes[:0]
+	var overflows []*dataVolume
+	for _, volume := range volumes {
+		if l.admit(volume.attributes, now) {
+			limited = append(limited, volume)
+			continue
+		}
+		i := 0
+		for i < len(overflows) && (overflows[i].bucket != volume.bucket || overflows[i].late != volume.late) {
+			i++
+		}
+		if i == len(overflows) {
+			overflow := &dataVolume{attributes: pcommon.NewMap(), bucket: volume.bucket, overflow: true, late: volume.late}
+			l.overflowLabels.CopyTo(overflow.attributes)
+			if volume.late {
+				overflow.attributes.PutStr(lateAttributeKey, "true")
+			}
+			overflows = append(overflows, overflow)
+		}
+		overflows[i].merge(volume)
+	}
+	for _, overflow := range overflows {
+		limited = append(limited, *overflow)
+	}
+	return limited
+}
+
+func (l *seriesLimiter) admit(attributes pcommon.Map, now time.Time) bool {
+	key := pdatautil.MapHash(attributes)
+	if series, ok := l.series[key]; ok {
+		series.lastSeen = now
+		return true
+	}
+
+	values := map[string]string{}
+	rejected := l.maxSeries > 0 && len(l.series) >= l.maxSeries
+	for label, maxValues := range l.maxLabelValues {
+		value, ok := attributes.Get(label)
+		if !ok {
+			continue
+		}
+		values[label] = value.AsString()
+		if l.labelValues[label][values[label]] == 0 && len(l.labelValues[label]) >= maxValues {
+			rejected = true
+		}
+	}
+	if rejected {
+		if !l.warned {
+			l.logger.Warn("datavolume series limit reached, new label sets are folded into the overflow series",
+				zap.Int("max_series", l.maxSeries), zap.Any("max_label_values", l.maxLabelValues))
+			l.warned = true
+		}
+		if _, ok := l.rejected[key]; ok || len(l.rejected) < l.maxRejected {
+			l.rejected[key] = now
+		}
+		return false
+	}
+
+	l.series[key] = &limitedSeries{lastSeen: now, values: values}
+	for label, value := range values {
+		l.labelValues[label][value]++
+	}
+	return true
+}
+
+// sweep forgets the admitted and rejected label sets that have not been seen within the expiration, making room for
+// new ones.
+func (l *seriesLimiter) sweep(now time.Time) {
+	if l.expiration == 0 {
+		return
+	}
+	for key, series := range l.series {
+		if now.Sub(series.lastSeen) <= l.expiration {
+			continue
+		}
+		for label, value := range series.values {
+			if l.labelValues[label][value]--; l.labelValues[label][value] == 0 {
+				delete(l.labelValues[label], value)
+			}
+		}
+		delete(l.series, key)
+	}
+	for key, lastSeen := range l.rejected {
+		if now.Sub(lastSeen) > l.expiration {
+			delete(l.rejected, key)
+		}
+	}
+}
+
+// rejectedSeries returns the number of distinct label sets that were folded into the overflow series and have not
+// expired since. Label sets beyond maxRejected are folded without being tracked, so the number saturates there.
+func (l *seriesLimiter) rejectedSeries() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int64(len(l.rejected))
+}
@@ -0,0 +1,86 @@
+package datavolumeconnector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func newLimiterVolume(service, pod string, bytes int64) dataVolume {
+	attributes := pcommon.NewMap()
+	attributes.PutStr("service.name", service)
+	attributes.PutStr("k8s.pod.name", pod)
+	return dataVolume{attributes: attributes, count: 1, bytes: bytes}
+}
+
+func TestSeriesLimiterMaxLabelValues(t *testing.T) {
+	limiter := newSeriesLimiter(0, map[string]int{"k8s.pod.name": 2}, time.Minute, zap.NewNop())
+	now := time.Unix(0, 0)
+
+	volumes := limiter.limit([]dataVolume{
+		newLimiterVolume("serviceA", "pod-1", 10),
+		newLimiterVolume("serviceB", "pod-1", 20),
+		newLimiterVolume("serviceA", "pod-2", 30),
+		newLimiterVolume("serviceA", "pod-3", 40),
+		newLimiterVolume("serviceB", "pod-4", 50),
+		newLimiterVolume("serviceB", "pod-4", 60),
+	}, now)
+	require.Len(t, volumes, 4)
+	overflow := volumes[3]
+	assert.True(t, overflow.overflow)
+	assert.Equal(t, map[string]any{overflowAttributeKey: "true"}, overflow.attributes.AsRaw())
+	assert.Equal(t, int64(3), overflow.count)
+	assert.Equal(t, int64(150), overflow.bytes)
+	assert.Equal(t, int64(2), limiter.rejectedSeries())
+
+	// once the pods expire, their values make room for new ones
+	volumes = limiter.limit([]dataVolume{newLimiterVolume("serviceA", "pod-3", 40)}, now.Add(2*time.Minute))
+	require.Len(t, volumes, 1)
+	assert.False(t, volumes[0].overflow)
+	assert.Equal(t, int64(0), limiter.rejectedSeries())
+}
+
+func TestSeriesLimiterMaxSeries(t *testing.T) {
+	limiter := newSeriesLimiter(1, nil, 0, zap.NewNop())
+	constantLabels := pcommon.NewMap()
+	constantLabels.PutStr(dataTypeAttributeKey, dataTypeLogsAttributeValue)
+	limiter.setConstantLabels(constantLabels)
+	now := time.Unix(0, 0)
+
+	volumes := limiter.limit([]dataVolume{newLimiterVolume("serviceA", "pod-1", 10)}, now)
+	require.Len(t, volumes, 1)
+	assert.False(t, volumes[0].overflow)
+
+	volumes = limiter.limit([]dataVolume{
+		newLimiterVolume("serviceB", "pod-1", 20),
+		newLimiterVolume("serviceA", "pod-1", 30),
+	}, now.Add(time.Hour))
+	require.Len(t, volumes, 2)
+	assert.Equal(t, "serviceA", volumes[0].attributes.AsRaw()["service.name"])
+	assert.True(t, volumes[1].overflow)
+	// the overflow series keeps the labels that every series carries
+	assert.Equal(t, map[string]any{dataTypeAttributeKey: dataTypeLogsAttributeValue, overflowAttributeKey: "true"}, volumes[1].attributes.AsRaw())
+	assert.Equal(t, int64(20), volumes[1].bytes)
+	assert.Equal(t, int64(1), limiter.rejectedSeries())
+}
+
+func TestSeriesLimiterMaxRejected(t *testing.T) {
+	limiter := newSeriesLimiter(1, nil, 0, zap.NewNop())
+	limiter.maxRejected = 2
+	now := time.Unix(0, 0)
+
+	volumes := limiter.limit([]dataVolume{
+		newLimiterVolume("serviceA", "pod-1", 10),
+		newLimiterVolume("serviceB", "pod-1", 20),
+		newLimiterVolume("serviceC", "pod-1", 30),
+		newLimiterVolume("serviceD", "pod-1", 40),
+	}, now)
+	require.Len(t, volumes, 2)
+	assert.Equal(t, int64(90), volumes[1].bytes)
+	// without expiration, the rejected label sets are tracked up to the maximum only
+	assert.Equal(t, int64(2), limiter.rejectedSeries())
+	assert.Len(t, limiter.rejected, 2)
+}
@@ -0,0 +1,122 @@
+package datavolumeconnector
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const (
+	outputLabelsResource    = "resource"
+	outputLabelsAttributes  = "attributes"
+	outputResourceEmpty     = "empty"
+	outputResourceCollector = "collector"
+)
+
+// OutputConfig defines where the labels of the output metrics are put.
+type OutputConfig struct {
+	// Where the output labels are put, either resource for one output resource per label set, or attributes for data point attributes under a single output resource. Defaults to resource.
+	Labels string `mapstructure:"labels"`
+	// The single output resource when labels are put on data point attributes, either empty or collector for the resource of the collector's own telemetry. Defaults to empty.
+	Resource string `mapstructure:"resource"`
+}
+
+// DataTypeConfig defines the label naming the signal that was measured.
+type DataTypeConfig struct {
+	// Leave the signal label out of the output metrics.
+	Disabled bool `mapstructure:"disabled"`
+	// The name of the signal label. Defaults to data_type.
+	Key string `mapstructure:"key"`
+	// The value of the signal label for logs. Defaults to logs.
+	Logs string `mapstructure:"logs"`
+	// The value of the signal label for traces. Defaults to traces.
+	Traces string `mapstructure:"traces"`
+	// The value of the signal label for metrics. Defaults to metrics.
+	Metrics string `mapstructure:"metrics"`
+	// The value of the signal label for profiles. Defaults to profiles.
+	Profiles string `mapstructure:"profiles"`
+}
+
+// label returns the signal label for one of the data type values, or an empty key if the label is disabled.
+func (c DataTypeConfig) label(dataType string) (string, string) {
+	if c.Disabled {
+		return "", ""
+	}
+	key := dataTypeAttributeKey
+	if c.Key != "" {
+		key = c.Key
+	}
+	value := ""
+	switch dataType {
+	case dataTypeLogsAttributeValue:
+		value = c.Logs
+	case dataTypeTracesAttributeValue:
+		value = c.Traces
+	case dataTypeMetricsAttributeValue:
+		value = c.Metrics
+	case dataTypeProfilesAttributeValue:
+		value = c.Profiles
+	}
+	if value == "" {
+		value = dataType
+	}
+	return key, value
+}
+
+// metricsOutput lays out the output metrics of a batch or flush, either as one resource per label set or as a single
+// resource with the labels on data point attributes.
+type metricsOutput struct {
+	metrics      pmetric.Metrics
+	onAttributes bool
+	resource     pcommon.Resource
+
+	// shared is the single scope of all label sets, only created when labels are put on attributes and there is output
+	shared *outputScope
+}
+
+func (c *connectorImp) newOutput() *metricsOutput {
+	return &metricsOutput{
+		metrics:      pmetric.NewMetrics(),
+		onAttributes: c.config.Output.Labels == outputLabelsAttributes,
+		resource:     c.outputResource,
+	}
+}
+
+// outputScope is the scope the output metrics of a label set are added to, with the labels their data points carry.
+type outputScope struct {
+	scope   pmetric.ScopeMetrics
+	metrics map[string]pmetric.Metric
+	labels  pcommon.Map
+}
+
+// scope returns the scope for the output metrics of a label set.
+func (o *metricsOutput) scope(labels pcommon.Map) outputScope {
+	if !o.onAttributes {
+		resourceMetrics := o.metrics.ResourceMetrics().AppendEmpty()
+		labels.CopyTo(resourceMetrics.Resource().Attributes())
+		return outputScope{
+			scope:   resourceMetrics.ScopeMetrics().AppendEmpty(),
+			metrics: map[string]pmetric.Metric{},
+			labels:  pcommon.NewMap(),
+		}
+	}
+	if o.shared == nil {
+		resourceMetrics := o.metrics.ResourceMetrics().AppendEmpty()
+		o.resource.CopyTo(resourceMetrics.Resource())
+		o.shared = &outputScope{
+			scope:   resourceMetrics.ScopeMetrics().AppendEmpty(),
+			metrics: map[string]pmetric.Metric{},
+		}
+	}
+	return outputScope{scope: o.shared.scope, metrics: o.shared.metrics, labels: labels}
+}
+
+// metric returns the metric of the scope with the given name, and whether it was just added.
+func (s outputScope) metric(name string) (pmetric.Metric, bool) {
+	if metric, ok := s.metrics[name]; ok {
+		return metric, false
+	}
+	metric := s.scope.Metrics().AppendEmpty()
+	metric.SetName(name)
+	s.metrics[name] = metric
+	return metric, true
+}
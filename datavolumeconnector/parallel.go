@@ -0,0 +1,71 @@
+package datavolumeconnector
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultParallelismThreshold = 10000
+
+// chunksPerWorker is how many ranges of resources each worker measures on average, so that workers that are done
+// early can take over ranges from workers that got larger resources.
+const chunksPerWorker = 4
+
+// measure measures the resources of a batch with measureResource. Batches holding at least the threshold of records
+// are measured by a bounded pool of workers: the resources are split into contiguous ranges, each range is measured
+// into a batch of its own, and those batches are merged in the order of their ranges, so the result is the same as
+// measuring the resources one after another. All ranges share the time the batch was received.
+func (c *connectorImp) measure(resources, records int, measureResource func(batch *batch, i int) error) (*batch, error) {
+	received := pcommon.NewTimestampFromTime(time.Now())
+	workers := min(c.config.Parallelism, resources)
+	if workers <= 1 || records < c.config.ParallelismThreshold {
+		batch := c.newBatch(received)
+		for i := 0; i < resources; i++ {
+			if err := measureResource(batch, i); err != nil {
+				return nil, err
+			}
+		}
+		return batch, nil
+	}
+
+	chunks := min(workers*chunksPerWorker, resources)
+	batches := make([]*batch, chunks)
+	errs := make([]error, chunks)
+	var next atomic.Int64
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				chunk := int(next.Add(1) - 1)
+				if chunk >= chunks {
+					return
+				}
+				batches[chunk] = c.newBatch(received)
+				for i := chunk * resources / chunks; i < (chunk+1)*resources/chunks; i++ {
+					if err := measureResource(batches[chunk], i); err != nil {
+						errs[chunk] = err
+						failed.Store(true)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	merged := batches[0]
+	for _, batch := range batches[1:] {
+		merged.merge(batch)
+	}
+	return merged, nil
+}
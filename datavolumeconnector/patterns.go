@@ -0,0 +1,131 @@
+package datavolumeconnector
+
+import (
+	"fmt"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+const defaultMaxMatchedAttributes = 10
+
+// isAttributePattern reports whether a label attribute key is a pattern rather than a plain key. Keys wrapped in
+// slashes are regular expressions, keys holding * or ? are globs.
+func isAttributePattern(key string) bool {
+	return isRegexpPattern(key) || strings.ContainsAny(key, "*?")
+}
+
+func isRegexpPattern(key string) bool {
+	return len(key) >= 2 && strings.HasPrefix(key, "/") && strings.HasSuffix(key, "/")
+}
+
+// compileAttributePattern compiles a label attribute pattern into a regular expression matching whole keys. In globs,
+// * matches any run of characters, including dots, and ? any single character.
+func compileAttributePattern(pattern string) (*regexp.Regexp, error) {
+	if isRegexpPattern(pattern) {
+		return regexp.Compile(pattern[1 : len(pattern)-1])
+	}
+	var expression strings.Builder
+	expression.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expression.WriteString(".*")
+		case '?':
+			expression.WriteString(".")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expression.WriteString("$")
+	return regexp.Compile(expression.String())
+}
+
+// splitAttributeKeys separates the plain keys of a label attribute list from its patterns.
+func splitAttributeKeys(keys []string) ([]string, []string) {
+	var plain, patterns []string
+	for _, key := range keys {
+		if isAttributePattern(key) {
+			patterns = append(patterns, key)
+		} else {
+			plain = append(plain, key)
+		}
+	}
+	return plain, patterns
+}
+
+// attributeMatcher labels with all attributes whose keys match any of its patterns and none of its exclusions, up to
+// a maximum number of keys.
+type attributeMatcher struct {
+	level    string
+	patterns []*regexp.Regexp
+	exclude  []*regexp.Regexp
+	max      int
+	logger   *zap.Logger
+	warned   atomic.Bool
+}
+
+// newAttributeMatcher returns the matcher of the patterns in a label attribute list, or nil if it has none.
+func newAttributeMatcher(level string, keys []string, cfg *Config, logger *zap.Logger) (*attributeMatcher, error) {
+	_, patterns := splitAttributeKeys(keys)
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	m := &attributeMatcher{level: level, max: cfg.MaxMatchedAttributes, logger: logger}
+	for _, pattern := range patterns {
+		compiled, err := compileAttributePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s attribute pattern %q: %w", level, pattern, err)
+		}
+		m.patterns = append(m.patterns, compiled)
+	}
+	for _, pattern := range cfg.ExcludeLabelAttributes {
+		compiled, err := compileAttributePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_label_attributes pattern %q: %w", pattern, err)
+		}
+		m.exclude = append(m.exclude, compiled)
+	}
+	return m, nil
+}
+
+// put copies the matching attributes of source onto the label set, in key order. Keys beyond the maximum are left
+// out, which is logged once.
+func (m *attributeMatcher) put(attributes pcommon.Map, source pcommon.Map) {
+	var keys []string
+	source.Range(func(key string, _ pcommon.Value) bool {
+		if m.matches(key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	sort.Strings(keys)
+	if m.max > 0 && len(keys) > m.max {
+		if m.warned.CompareAndSwap(false, true) {
+			m.logger.Warn("datavolume label attribute patterns match more keys than allowed, the remaining keys are left out",
+				zap.String("level", m.level), zap.Int("max_matched_attributes", m.max), zap.Strings("dropped", keys[m.max:]))
+		}
+		keys = keys[:m.max]
+	}
+	for _, key := range keys {
+		value, _ := source.Get(key)
+		value.CopyTo(attributes.PutEmpty(key))
+	}
+}
+
+func (m *attributeMatcher) matches(key string) bool {
+	for _, exclude := range m.exclude {
+		if exclude.MatchString(key) {
+			return false
+		}
+	}
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
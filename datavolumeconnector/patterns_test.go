@@ -0,0 +1,53 @@
+package datavolumeconnector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+func TestCompileAttributePattern(t *testing.T) {
+	for pattern, keys := range map[string]map[string]bool{
+		"mdai.*":          {"mdai.tenant": true, "mdai.tenant.id": true, "mdai": false, "xmdai.tenant": false},
+		"k8s.*.name":      {"k8s.pod.name": true, "k8s.namespace.name": true, "k8s.pod.uid": false, "k8s_pod_name": false},
+		"cloud.regio?":    {"cloud.region": true, "cloud.regions": false},
+		"/^cloud\\./":     {"cloud.region": true, "cloud.account.id": true, "k8s.cloud.id": false},
+		"/(zone|region)/": {"cloud.availability_zone": true, "region": true, "service.name": false},
+	} {
+		compiled, err := compileAttributePattern(pattern)
+		require.NoError(t, err, pattern)
+		for key, matches := range keys {
+			assert.Equal(t, matches, compiled.MatchString(key), "%s %s", pattern, key)
+		}
+	}
+
+	_, err := compileAttributePattern("/(/")
+	assert.Error(t, err)
+}
+
+func TestAttributeMatcherMaxMatched(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	matcher, err := newAttributeMatcher("resource", []string{"service.name", "k8s.*"}, &Config{
+		ExcludeLabelAttributes: []string{"k8s.pod.uid"},
+		MaxMatchedAttributes:   2,
+	}, zap.New(core))
+	require.NoError(t, err)
+
+	source := pcommon.NewMap()
+	source.PutStr("service.name", "checkout")
+	source.PutStr("k8s.pod.uid", "0c5b6e2a")
+	source.PutStr("k8s.pod.name", "checkout-7d9f8")
+	source.PutStr("k8s.namespace.name", "shop")
+	source.PutStr("k8s.node.name", "node-1")
+	for i := 0; i < 2; i++ {
+		attributes := pcommon.NewMap()
+		matcher.put(attributes, source)
+		// plain keys are left to the resource dimensions, the matched keys are capped in key order
+		assert.Equal(t, map[string]any{"k8s.namespace.name": "shop", "k8s.node.name": "node-1"}, attributes.AsRaw())
+	}
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, []any{"k8s.pod.name"}, logs.All()[0].ContextMap()["dropped"])
+}
@@ -0,0 +1,117 @@
+package datavolumeconnector
+
+import (
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatautil"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"sync"
+	"time"
+)
+
+// seriesState is what the connector remembers about an output series between emissions.
+type seriesState struct {
+	start      pcommon.Timestamp
+	last       pcommon.Timestamp
+	count      int64
+	profiles   int64
+	bytes      int64
+	sizes      sizeHistogram
+	lags       sizeHistogram
+	anomalies  timestampAnomalies
+	components byteComponents
+	lastSeen   time.Time
+}
+
+// seriesTracker keeps per-series start timestamps and, for cumulative temporality, running totals.
+type seriesTracker struct {
+	mu         sync.Mutex
+	cumulative bool
+	// buckets is set when measurements are bucketed by event time, so that every delta measurement starts at its bucket
+	buckets    bool
+	expiration time.Duration
+	series     map[[16]byte]*seriesState
+}
+
+func newSeriesTracker(temporality string, expiration time.Duration, buckets bool) *seriesTracker {
+	return &seriesTracker{
+		cumulative: temporality == temporalityCumulative,
+		buckets:    buckets,
+		expiration: expiration,
+		series:     map[[16]byte]*seriesState{},
+	}
+}
+
+// record updates the series of the given measurement and returns the start timestamp and values to report for it.
+// windowStart is the beginning of the period the measurement covers and is used as the start of new series. Delta
+// measurements start where the previous one of the series ended, except for event time buckets, which may leave gaps
+// between them and start at their own beginning.
+func (t *seriesTracker) record(volume dataVolume, windowStart, timestamp pcommon.Timestamp) (pcommon.Timestamp, dataVolume) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := timestamp.AsTime()
+	key := pdatautil.MapHash(volume.attributes)
+	state, ok := t.series[key]
+	if !ok || t.expired(state, now) {
+		state = &seriesState{start: windowStart}
+		t.series[key] = state
+	}
+
+	start := state.start
+	if t.cumulative {
+		state.count += volume.count
+		state.profiles += volume.profiles
+		state.bytes += volume.bytes
+		state.components.add(volume.components)
+		state.anomalies.add(volume.anomalies)
+		state.sizes = mergeHistogram(state.sizes, volume.sizes)
+		state.lags = mergeHistogram(state.lags, volume.lags)
+		volume.count, volume.profiles, volume.bytes, volume.components = state.count, state.profiles, state.bytes, state.components
+		volume.anomalies = state.anomalies
+		if state.sizes != nil {
+			volume.sizes = state.sizes.clone()
+		}
+		if state.lags != nil {
+			volume.lags = state.lags.clone()
+		}
+	} else if t.buckets {
+		start = windowStart
+	} else if state.last != 0 {
+		start = state.last
+	}
+
+	state.last = timestamp
+	state.lastSeen = now
+	return start, volume
+}
+
+// mergeHistogram adds the histogram of a measurement to the running total of a series and returns the total.
+func mergeHistogram(total, histogram sizeHistogram) sizeHistogram {
+	if histogram == nil {
+		return total
+	}
+	if total == nil {
+		return histogram.clone()
+	}
+	total.merge(histogram)
+	return total
+}
+
+// sweep drops the state of every series that has not been seen within the expiration.
+func (t *seriesTracker) sweep(now time.Time) {
+	if t.expiration == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, state := range t.series {
+		if t.expired(state, now) {
+			delete(t.series, key)
+		}
+	}
+}
+
+func (t *seriesTracker) expired(state *seriesState, now time.Time) bool {
+	return t.expiration > 0 && now.Sub(state.lastSeen) > t.expiration
+}
@@ -0,0 +1,214 @@
+package datavolumeconnector
+
+import "fmt"
+
+// SignalsConfig holds the per-signal overrides of the shared options.
+type SignalsConfig struct {
+	// Overrides for the logs the connector consumes.
+	Logs *SignalConfig `mapstructure:"logs"`
+	// Overrides for the traces the connector consumes.
+	Traces *SignalConfig `mapstructure:"traces"`
+	// Overrides for the metrics the connector consumes.
+	Metrics *SignalConfig `mapstructure:"metrics"`
+	// Overrides for the profiles the connector consumes.
+	Profiles *SignalConfig `mapstructure:"profiles"`
+}
+
+// SignalConfig overrides the shared options for a single signal. Options that are not present keep their shared value,
+// while options that are present replace it, also when they are empty or false, so a signal can turn off a shared
+// metric or label.
+type SignalConfig struct {
+	// See Config.LabelResourceAttributes.
+	LabelResourceAttributes []string `mapstructure:"label_resource_attributes"`
+	// See Config.LabelRecordAttributes.
+	LabelRecordAttributes []string `mapstructure:"label_record_attributes"`
+	// See Config.LabelScopeName.
+	LabelScopeName *bool `mapstructure:"label_scope_name"`
+	// See Config.LabelScopeVersion.
+	LabelScopeVersion *bool `mapstructure:"label_scope_version"`
+	// See Config.LabelScopeAttributes.
+	LabelScopeAttributes []string `mapstructure:"label_scope_attributes"`
+	// See Config.LabelSeverityNumber.
+	LabelSeverityNumber *bool `mapstructure:"label_severity_number"`
+	// See Config.LabelSeverityText.
+	LabelSeverityText *bool `mapstructure:"label_severity_text"`
+	// See Config.LabelSpanKind.
+	LabelSpanKind *bool `mapstructure:"label_span_kind"`
+	// See Config.LabelSpanStatusCode.
+	LabelSpanStatusCode *bool `mapstructure:"label_span_status_code"`
+	// See Config.LabelMetricName.
+	LabelMetricName *bool `mapstructure:"label_metric_name"`
+	// See Config.LabelMetricType.
+	LabelMetricType *bool `mapstructure:"label_metric_type"`
+	// See Config.StaticLabels.
+	StaticLabels map[string]string `mapstructure:"static_labels"`
+	// See Config.Dimensions.
+	Dimensions []DimensionConfig `mapstructure:"dimensions"`
+	// See Config.Labels.
+	Labels []LabelConfig `mapstructure:"labels"`
+	// See Config.BytesMetricName.
+	BytesMetricName *string `mapstructure:"bytes_metric_name"`
+	// See Config.CountMetricName.
+	CountMetricName *string `mapstructure:"count_metric_name"`
+	// See Config.ProfileCountMetricName.
+	ProfileCountMetricName *string `mapstructure:"profile_count_metric_name"`
+	// See Config.CountUnit.
+	CountUnit *string `mapstructure:"count_unit"`
+	// See Config.BytesByComponent.
+	BytesByComponent *bool `mapstructure:"bytes_by_component"`
+	// See Config.SizeHistogramMetricName.
+	SizeHistogramMetricName *string `mapstructure:"size_histogram_metric_name"`
+	// See Config.LagHistogramMetricName.
+	LagHistogramMetricName *string `mapstructure:"lag_histogram_metric_name"`
+	// See Config.TimestampAnomalyMetricName.
+	TimestampAnomalyMetricName *string `mapstructure:"timestamp_anomaly_metric_name"`
+	// See Config.Metrics.
+	Metrics []MetricConfig `mapstructure:"metrics"`
+	// See Config.MaxSeries.
+	MaxSeries *int `mapstructure:"max_series"`
+	// See Config.MaxLabelValues.
+	MaxLabelValues map[string]int `mapstructure:"max_label_values"`
+}
+
+// validateProfiles rejects the options of a profiles block that profiles cannot honor. There is no OTTL context for
+// profiles, so they can neither be selected by conditions nor labeled by record dimensions.
+func (c *SignalConfig) validateProfiles() error {
+	if err := validateProfileDimensions(c.Dimensions); err != nil {
+		return err
+	}
+	for _, metric := range c.Metrics {
+		if !metric.Conditions.empty() {
+			return fmt.Errorf("metric %q: conditions are not supported for profiles", metric.Name)
+		}
+		if err := validateProfileDimensions(metric.Dimensions); err != nil {
+			return fmt.Errorf("metric %q: %w", metric.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateProfileDimensions rejects dimensions evaluated in the context of a record, which profiles do not have.
+func validateProfileDimensions(dimensions []DimensionConfig) error {
+	for _, dimension := range dimensions {
+		if dimension.recordContext() {
+			return fmt.Errorf("dimension %q: context %q is not supported for profiles", dimension.Name, dimension.Context)
+		}
+	}
+	return nil
+}
+
+// overrides returns the overrides of one of the data type values, or nil if there are none.
+func (c SignalsConfig) overrides(signal string) *SignalConfig {
+	switch signal {
+	case dataTypeLogsAttributeValue:
+		return c.Logs
+	case dataTypeTracesAttributeValue:
+		return c.Traces
+	case dataTypeMetricsAttributeValue:
+		return c.Metrics
+	case dataTypeProfilesAttributeValue:
+		return c.Profiles
+	}
+	return nil
+}
+
+// forSignal returns the options of the connector for one of the data type values, the shared options with the
+// overrides of the signal applied.
+func (c *Config) forSignal(signal string) *Config {
+	merged := *c
+	merged.Signals = SignalsConfig{}
+	if signal != dataTypeProfilesAttributeValue {
+		// only profiles are counted both as samples and as profiles
+		merged.ProfileCountMetricName = ""
+	}
+	if signal != dataTypeLogsAttributeValue && signal != dataTypeTracesAttributeValue {
+		// only log records and spans carry trace IDs to link exemplars to
+		merged.MaxExemplars = 0
+	}
+	overrides := c.Signals.overrides(signal)
+	if overrides == nil {
+		return &merged
+	}
+	if overrides.LabelResourceAttributes != nil {
+		merged.LabelResourceAttributes = overrides.LabelResourceAttributes
+	}
+	if overrides.LabelRecordAttributes != nil {
+		merged.LabelRecordAttributes = overrides.LabelRecordAttributes
+	}
+	if overrides.LabelScopeName != nil {
+		merged.LabelScopeName = *overrides.LabelScopeName
+	}
+	if overrides.LabelScopeVersion != nil {
+		merged.LabelScopeVersion = *overrides.LabelScopeVersion
+	}
+	if overrides.LabelScopeAttributes != nil {
+		merged.LabelScopeAttributes = overrides.LabelScopeAttributes
+	}
+	if overrides.LabelSeverityNumber != nil {
+		merged.LabelSeverityNumber = *overrides.LabelSeverityNumber
+	}
+	if overrides.LabelSeverityText != nil {
+		merged.LabelSeverityText = *overrides.LabelSeverityText
+	}
+	if overrides.LabelSpanKind != nil {
+		merged.LabelSpanKind = *overrides.LabelSpanKind
+	}
+	if overrides.LabelSpanStatusCode != nil {
+		merged.LabelSpanStatusCode = *overrides.LabelSpanStatusCode
+	}
+	if overrides.LabelMetricName != nil {
+		merged.LabelMetricName = *overrides.LabelMetricName
+	}
+	if overrides.LabelMetricType != nil {
+		merged.LabelMetricType = *overrides.LabelMetricType
+	}
+	if overrides.StaticLabels != nil {
+		merged.StaticLabels = overrides.StaticLabels
+	}
+	if overrides.Dimensions != nil {
+		merged.Dimensions = overrides.Dimensions
+	}
+	if overrides.Labels != nil {
+		merged.Labels = overrides.Labels
+	}
+	if overrides.BytesMetricName != nil {
+		merged.BytesMetricName = *overrides.BytesMetricName
+	}
+	if overrides.CountMetricName != nil {
+		merged.CountMetricName = *overrides.CountMetricName
+	}
+	if overrides.ProfileCountMetricName != nil && signal == dataTypeProfilesAttributeValue {
+		merged.ProfileCountMetricName = *overrides.ProfileCountMetricName
+	}
+	if overrides.CountUnit != nil {
+		merged.CountUnit = *overrides.CountUnit
+	}
+	if overrides.BytesByComponent != nil {
+		merged.BytesByComponent = *overrides.BytesByComponent
+	}
+	if overrides.SizeHistogramMetricName != nil {
+		merged.SizeHistogramMetricName = *overrides.SizeHistogramMetricName
+	}
+	if overrides.LagHistogramMetricName != nil {
+		merged.LagHistogramMetricName = *overrides.LagHistogramMetricName
+	}
+	if overrides.TimestampAnomalyMetricName != nil {
+		merged.TimestampAnomalyMetricName = *overrides.TimestampAnomalyMetricName
+	}
+	if overrides.Metrics != nil {
+		merged.Metrics = overrides.Metrics
+	}
+	if overrides.MaxSeries != nil {
+		merged.MaxSeries = *overrides.MaxSeries
+	}
+	if overrides.MaxLabelValues != nil {
+		merged.MaxLabelValues = overrides.MaxLabelValues
+	}
+	return &merged
+}
+
+// hasMetrics reports whether the options name any output metric.
+func (c *Config) hasMetrics() bool {
+	return c.BytesMetricName != "" || c.CountMetricName != "" || c.ProfileCountMetricName != "" || c.SizeHistogramMetricName != "" ||
+		c.LagHistogramMetricName != "" || c.TimestampAnomalyMetricName != "" || len(c.Metrics) > 0
+}
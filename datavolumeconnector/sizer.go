@@ -0,0 +1,550 @@
+package datavolumeconnector
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pprofile"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// emptyMessageFieldSize is the encoded size of an empty, non-nullable message field such as a resource or scope.
+const emptyMessageFieldSize = 2
+
+// Sizes are computed field by field from pdata, following the rules of the generated OTLP protobuf code pdata
+// marshals with, so nothing has to be copied or marshaled. Unless noted otherwise the returned sizes include the tag
+// and length prefix of the resource, scope or record, so they are the number of bytes it occupies inside its payload,
+// resource or scope. Nearly all fields have field numbers below 16 and therefore single byte tags, the exceptions are
+// noted where they are sized.
+
+// resourceLogsSize returns the size of a payload holding nothing but the resource.
+func resourceLogsSize(resourceLogs plog.ResourceLogs) int {
+	size := messageFieldSize(resourceMessageSize(resourceLogs.Resource())) + stringFieldSize(resourceLogs.SchemaUrl())
+	for i := 0; i < resourceLogs.ScopeLogs().Len(); i++ {
+		size += scopeLogsSize(resourceLogs.ScopeLogs().At(i))
+	}
+	return messageFieldSize(size)
+}
+
+// resourceSpansSize returns the size of a payload holding nothing but the resource.
+func resourceSpansSize(resourceSpans ptrace.ResourceSpans) int {
+	size := messageFieldSize(resourceMessageSize(resourceSpans.Resource())) + stringFieldSize(resourceSpans.SchemaUrl())
+	for i := 0; i < resourceSpans.ScopeSpans().Len(); i++ {
+		size += scopeSpansSize(resourceSpans.ScopeSpans().At(i))
+	}
+	return messageFieldSize(size)
+}
+
+// resourceMetricsSize returns the size of a payload holding nothing but the resource.
+func resourceMetricsSize(resourceMetrics pmetric.ResourceMetrics) int {
+	size := messageFieldSize(resourceMessageSize(resourceMetrics.Resource())) + stringFieldSize(resourceMetrics.SchemaUrl())
+	for i := 0; i < resourceMetrics.ScopeMetrics().Len(); i++ {
+		size += scopeMetricsSize(resourceMetrics.ScopeMetrics().At(i))
+	}
+	return messageFieldSize(size)
+}
+
+// resourceProfilesSize returns the size of a payload holding nothing but the resource.
+func resourceProfilesSize(resourceProfiles pprofile.ResourceProfiles) int {
+	size := messageFieldSize(resourceMessageSize(resourceProfiles.Resource())) + stringFieldSize(resourceProfiles.SchemaUrl())
+	for i := 0; i < resourceProfiles.ScopeProfiles().Len(); i++ {
+		size += scopeProfilesSize(resourceProfiles.ScopeProfiles().At(i))
+	}
+	return messageFieldSize(size)
+}
+
+func scopeLogsSize(scopeLogs plog.ScopeLogs) int {
+	size := messageFieldSize(scopeMessageSize(scopeLogs.Scope())) + stringFieldSize(scopeLogs.SchemaUrl())
+	for i := 0; i < scopeLogs.LogRecords().Len(); i++ {
+		size += logRecordSize(scopeLogs.LogRecords().At(i))
+	}
+	return messageFieldSize(size)
+}
+
+func scopeSpansSize(scopeSpans ptrace.ScopeSpans) int {
+	size := messageFieldSize(scopeMessageSize(scopeSpans.Scope())) + stringFieldSize(scopeSpans.SchemaUrl())
+	for i := 0; i < scopeSpans.Spans().Len(); i++ {
+		size += spanSize(scopeSpans.Spans().At(i))
+	}
+	return messageFieldSize(size)
+}
+
+func scopeMetricsSize(scopeMetrics pmetric.ScopeMetrics) int {
+	size := messageFieldSize(scopeMessageSize(scopeMetrics.Scope())) + stringFieldSize(scopeMetrics.SchemaUrl())
+	for i := 0; i < scopeMetrics.Metrics().Len(); i++ {
+		size += metricSize(scopeMetrics.Metrics().At(i))
+	}
+	return messageFieldSize(size)
+}
+
+func scopeProfilesSize(scopeProfiles pprofile.ScopeProfiles) int {
+	size := messageFieldSize(scopeMessageSize(scopeProfiles.Scope())) + stringFieldSize(scopeProfiles.SchemaUrl())
+	for i := 0; i < scopeProfiles.Profiles().Len(); i++ {
+		size += profileSize(scopeProfiles.Profiles().At(i))
+	}
+	return messageFieldSize(size)
+}
+
+// resourceMessageSize returns the size of the fields of a resource, without its own tag and length prefix.
+func resourceMessageSize(resource pcommon.Resource) int {
+	return attributesSize(resource.Attributes()) + varintFieldSize(uint64(resource.DroppedAttributesCount()))
+}
+
+// scopeMessageSize returns the size of the fields of a scope, without its own tag and length prefix.
+func scopeMessageSize(scope pcommon.InstrumentationScope) int {
+	return stringFieldSize(scope.Name()) + stringFieldSize(scope.Version()) + attributesSize(scope.Attributes()) +
+		varintFieldSize(uint64(scope.DroppedAttributesCount()))
+}
+
+func logRecordSize(record plog.LogRecord) int {
+	return messageFieldSize(logRecordFieldsSize(record))
+}
+
+// logRecordFieldsSize returns the size of the fields of a log record, without its own tag and length prefix.
+func logRecordFieldsSize(record plog.LogRecord) int {
+	return fixed64FieldSize(record.Timestamp() != 0) +
+		varintFieldSize(uint64(int64(record.SeverityNumber()))) +
+		stringFieldSize(record.SeverityText()) +
+		messageFieldSize(valueSize(record.Body())) +
+		attributesSize(record.Attributes()) +
+		varintFieldSize(uint64(record.DroppedAttributesCount())) +
+		fixed32FieldSize(record.Flags() != 0) +
+		idFieldSize(record.TraceID().IsEmpty(), len(record.TraceID())) +
+		idFieldSize(record.SpanID().IsEmpty(), len(record.SpanID())) +
+		fixed64FieldSize(record.ObservedTimestamp() != 0) +
+		stringFieldSize(record.EventName())
+}
+
+func spanSize(span ptrace.Span) int {
+	return messageFieldSize(spanFieldsSize(span))
+}
+
+// spanFieldsSize returns the size of the fields of a span, without its own tag and length prefix.
+func spanFieldsSize(span ptrace.Span) int {
+	size := idFieldSize(span.TraceID().IsEmpty(), len(span.TraceID())) +
+		idFieldSize(span.SpanID().IsEmpty(), len(span.SpanID())) +
+		stringFieldSize(span.TraceState().AsRaw()) +
+		idFieldSize(span.ParentSpanID().IsEmpty(), len(span.ParentSpanID())) +
+		stringFieldSize(span.Name()) +
+		varintFieldSize(uint64(int64(span.Kind()))) +
+		fixed64FieldSize(span.StartTimestamp() != 0) +
+		fixed64FieldSize(span.EndTimestamp() != 0) +
+		attributesSize(span.Attributes()) +
+		varintFieldSize(uint64(span.DroppedAttributesCount())) +
+		eventsLinksSize(span) +
+		varintFieldSize(uint64(span.DroppedEventsCount())) +
+		varintFieldSize(uint64(span.DroppedLinksCount())) +
+		messageFieldSize(stringFieldSize(span.Status().Message())+varintFieldSize(uint64(int64(span.Status().Code()))))
+	if span.Flags() != 0 {
+		// the flags are field 16, which takes a two byte tag
+		size += 2 + 4
+	}
+	return size
+}
+
+// eventsLinksSize returns the size of the events and links fields of a span.
+func eventsLinksSize(span ptrace.Span) int {
+	size := 0
+	for i := 0; i < span.Events().Len(); i++ {
+		event := span.Events().At(i)
+		size += messageFieldSize(fixed64FieldSize(event.Timestamp() != 0) + stringFieldSize(event.Name()) +
+			attributesSize(event.Attributes()) + varintFieldSize(uint64(event.DroppedAttributesCount())))
+	}
+	for i := 0; i < span.Links().Len(); i++ {
+		link := span.Links().At(i)
+		size += messageFieldSize(idFieldSize(link.TraceID().IsEmpty(), len(link.TraceID())) +
+			idFieldSize(link.SpanID().IsEmpty(), len(link.SpanID())) +
+			stringFieldSize(link.TraceState().AsRaw()) +
+			attributesSize(link.Attributes()) +
+			varintFieldSize(uint64(link.DroppedAttributesCount())) +
+			fixed32FieldSize(link.Flags() != 0))
+	}
+	return size
+}
+
+func metricSize(metric pmetric.Metric) int {
+	return messageFieldSize(metricFieldsSize(metric))
+}
+
+// metricFieldsSize returns the size of the fields of a metric, without its own tag and length prefix.
+func metricFieldsSize(metric pmetric.Metric) int {
+	size := stringFieldSize(metric.Name()) + stringFieldSize(metric.Description()) + stringFieldSize(metric.Unit()) +
+		attributesSize(metric.Metadata())
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		data := 0
+		for i := 0; i < metric.Gauge().DataPoints().Len(); i++ {
+			data += numberDataPointSize(metric.Gauge().DataPoints().At(i))
+		}
+		size += messageFieldSize(data)
+	case pmetric.MetricTypeSum:
+		data := varintFieldSize(uint64(int64(metric.Sum().AggregationTemporality())))
+		if metric.Sum().IsMonotonic() {
+			data += 2
+		}
+		for i := 0; i < metric.Sum().DataPoints().Len(); i++ {
+			data += numberDataPointSize(metric.Sum().DataPoints().At(i))
+		}
+		size += messageFieldSize(data)
+	case pmetric.MetricTypeHistogram:
+		data := varintFieldSize(uint64(int64(metric.Histogram().AggregationTemporality())))
+		for i := 0; i < metric.Histogram().DataPoints().Len(); i++ {
+			data += histogramDataPointSize(metric.Histogram().DataPoints().At(i))
+		}
+		size += messageFieldSize(data)
+	case pmetric.MetricTypeExponentialHistogram:
+		data := varintFieldSize(uint64(int64(metric.ExponentialHistogram().AggregationTemporality())))
+		for i := 0; i < metric.ExponentialHistogram().DataPoints().Len(); i++ {
+			data += exponentialHistogramDataPointSize(metric.ExponentialHistogram().DataPoints().At(i))
+		}
+		size += messageFieldSize(data)
+	case pmetric.MetricTypeSummary:
+		data := 0
+		for i := 0; i < metric.Summary().DataPoints().Len(); i++ {
+			data += summaryDataPointSize(metric.Summary().DataPoints().At(i))
+		}
+		size += messageFieldSize(data)
+	}
+	return size
+}
+
+// profileSize returns the size of a profile with its lookup tables. The link indices of samples are not accessible
+// through pdata, so they are not included.
+func profileSize(profile pprofile.Profile) int {
+	return messageFieldSize(profileFieldsSize(profile))
+}
+
+// profileFieldsSize returns the size of the fields of a profile, without its own tag and length prefix.
+func profileFieldsSize(profile pprofile.Profile) int {
+	size := 0
+	for i := 0; i < profile.SampleType().Len(); i++ {
+		size += messageFieldSize(valueTypeSize(profile.SampleType().At(i)))
+	}
+	for i := 0; i < profile.Sample().Len(); i++ {
+		sample := profile.Sample().At(i)
+		size += messageFieldSize(varintFieldSize(uint64(int64(sample.LocationsStartIndex()))) +
+			varintFieldSize(uint64(int64(sample.LocationsLength()))) +
+			packedInt64FieldSize(sample.Value()) +
+			packedInt32FieldSize(sample.AttributeIndices()) +
+			packedUint64FieldSize(sample.TimestampsUnixNano()))
+	}
+	for i := 0; i < profile.MappingTable().Len(); i++ {
+		mapping := profile.MappingTable().At(i)
+		size += messageFieldSize(varintFieldSize(mapping.MemoryStart()) +
+			varintFieldSize(mapping.MemoryLimit()) +
+			varintFieldSize(mapping.FileOffset()) +
+			varintFieldSize(uint64(int64(mapping.FilenameStrindex()))) +
+			packedInt32FieldSize(mapping.AttributeIndices()) +
+			boolFieldSize(mapping.HasFunctions()) +
+			boolFieldSize(mapping.HasFilenames()) +
+			boolFieldSize(mapping.HasLineNumbers()) +
+			boolFieldSize(mapping.HasInlineFrames()))
+	}
+	for i := 0; i < profile.LocationTable().Len(); i++ {
+		location := profile.LocationTable().At(i)
+		locationSize := varintFieldSize(location.Address()) + boolFieldSize(location.IsFolded()) +
+			packedInt32FieldSize(location.AttributeIndices())
+		if location.HasMappingIndex() {
+			// the mapping index is encoded whenever it is set, even when it is zero
+			locationSize += protowire.SizeTag(1) + protowire.SizeVarint(uint64(int64(location.MappingIndex())))
+		}
+		for j := 0; j < location.Line().Len(); j++ {
+			line := location.Line().At(j)
+			locationSize += messageFieldSize(varintFieldSize(uint64(int64(line.FunctionIndex()))) +
+				varintFieldSize(uint64(line.Line())) + varintFieldSize(uint64(line.Column())))
+		}
+		size += messageFieldSize(locationSize)
+	}
+	size += packedInt32FieldSize(profile.LocationIndices())
+	for i := 0; i < profile.FunctionTable().Len(); i++ {
+		function := profile.FunctionTable().At(i)
+		size += messageFieldSize(varintFieldSize(uint64(int64(function.NameStrindex()))) +
+			varintFieldSize(uint64(int64(function.SystemNameStrindex()))) +
+			varintFieldSize(uint64(int64(function.FilenameStrindex()))) +
+			varintFieldSize(uint64(function.StartLine())))
+	}
+	size += profileAttributeTableSize(profile)
+	for i := 0; i < profile.AttributeUnits().Len(); i++ {
+		unit := profile.AttributeUnits().At(i)
+		size += messageFieldSize(varintFieldSize(uint64(int64(unit.AttributeKeyStrindex()))) +
+			varintFieldSize(uint64(int64(unit.UnitStrindex()))))
+	}
+	for i := 0; i < profile.LinkTable().Len(); i++ {
+		link := profile.LinkTable().At(i)
+		size += messageFieldSize(idFieldSize(link.TraceID().IsEmpty(), len(link.TraceID())) +
+			idFieldSize(link.SpanID().IsEmpty(), len(link.SpanID())))
+	}
+	for i := 0; i < profile.StringTable().Len(); i++ {
+		size += messageFieldSize(len(profile.StringTable().At(i)))
+	}
+	size += varintFieldSize(uint64(profile.Time())) +
+		varintFieldSize(uint64(profile.Duration())) +
+		messageFieldSize(valueTypeSize(profile.PeriodType())) +
+		varintFieldSize(uint64(profile.Period())) +
+		packedInt32FieldSize(profile.CommentStrindices())
+	// the remaining fields have field numbers of 16 and above, which take two byte tags
+	if profile.DefaultSampleTypeStrindex() != 0 {
+		size += 1 + varintFieldSize(uint64(int64(profile.DefaultSampleTypeStrindex())))
+	}
+	size += 1 + idFieldSize(profile.ProfileID().IsEmpty(), len(profile.ProfileID()))
+	if profile.DroppedAttributesCount() != 0 {
+		size += 1 + varintFieldSize(uint64(profile.DroppedAttributesCount()))
+	}
+	if profile.OriginalPayloadFormat() != "" {
+		size += 1 + stringFieldSize(profile.OriginalPayloadFormat())
+	}
+	if profile.OriginalPayload().Len() > 0 {
+		size += 1 + messageFieldSize(profile.OriginalPayload().Len())
+	}
+	if profile.AttributeIndices().Len() > 0 {
+		size += 1 + packedInt32FieldSize(profile.AttributeIndices())
+	}
+	return size
+}
+
+// profileAttributeTableSize returns the size of the attribute table of a profile, which holds the attributes of the
+// profile and of its samples, mappings and locations.
+func profileAttributeTableSize(profile pprofile.Profile) int {
+	size := 0
+	for i := 0; i < profile.AttributeTable().Len(); i++ {
+		attribute := profile.AttributeTable().At(i)
+		size += messageFieldSize(stringFieldSize(attribute.Key()) + messageFieldSize(valueSize(attribute.Value())))
+	}
+	return size
+}
+
+func valueTypeSize(valueType pprofile.ValueType) int {
+	return varintFieldSize(uint64(int64(valueType.TypeStrindex()))) +
+		varintFieldSize(uint64(int64(valueType.UnitStrindex()))) +
+		varintFieldSize(uint64(int64(valueType.AggregationTemporality())))
+}
+
+// The data point sizes are the number of bytes the data point occupies in the data of its metric, their fields sizes
+// leave out its tag and length prefix.
+
+func numberDataPointSize(dataPoint pmetric.NumberDataPoint) int {
+	return messageFieldSize(numberDataPointFieldsSize(dataPoint))
+}
+
+func numberDataPointFieldsSize(dataPoint pmetric.NumberDataPoint) int {
+	size := attributesSize(dataPoint.Attributes()) +
+		fixed64FieldSize(dataPoint.StartTimestamp() != 0) +
+		fixed64FieldSize(dataPoint.Timestamp() != 0) +
+		fixed64FieldSize(dataPoint.ValueType() != pmetric.NumberDataPointValueTypeEmpty) +
+		exemplarsSize(dataPoint.Exemplars()) +
+		varintFieldSize(uint64(dataPoint.Flags()))
+	return size
+}
+
+func histogramDataPointSize(dataPoint pmetric.HistogramDataPoint) int {
+	return messageFieldSize(histogramDataPointFieldsSize(dataPoint))
+}
+
+func histogramDataPointFieldsSize(dataPoint pmetric.HistogramDataPoint) int {
+	size := attributesSize(dataPoint.Attributes()) +
+		fixed64FieldSize(dataPoint.StartTimestamp() != 0) +
+		fixed64FieldSize(dataPoint.Timestamp() != 0) +
+		fixed64FieldSize(dataPoint.Count() != 0) +
+		fixed64FieldSize(dataPoint.HasSum()) +
+		packedFixed64FieldSize(dataPoint.BucketCounts().Len()) +
+		packedFixed64FieldSize(dataPoint.ExplicitBounds().Len()) +
+		exemplarsSize(dataPoint.Exemplars()) +
+		varintFieldSize(uint64(dataPoint.Flags())) +
+		fixed64FieldSize(dataPoint.HasMin()) +
+		fixed64FieldSize(dataPoint.HasMax())
+	return size
+}
+
+func exponentialHistogramDataPointSize(dataPoint pmetric.ExponentialHistogramDataPoint) int {
+	return messageFieldSize(exponentialHistogramDataPointFieldsSize(dataPoint))
+}
+
+func exponentialHistogramDataPointFieldsSize(dataPoint pmetric.ExponentialHistogramDataPoint) int {
+	size := attributesSize(dataPoint.Attributes()) +
+		fixed64FieldSize(dataPoint.StartTimestamp() != 0) +
+		fixed64FieldSize(dataPoint.Timestamp() != 0) +
+		fixed64FieldSize(dataPoint.Count() != 0) +
+		fixed64FieldSize(dataPoint.HasSum()) +
+		varintFieldSize(protowire.EncodeZigZag(int64(dataPoint.Scale()))) +
+		fixed64FieldSize(dataPoint.ZeroCount() != 0) +
+		messageFieldSize(bucketsSize(dataPoint.Positive())) +
+		messageFieldSize(bucketsSize(dataPoint.Negative())) +
+		varintFieldSize(uint64(dataPoint.Flags())) +
+		exemplarsSize(dataPoint.Exemplars()) +
+		fixed64FieldSize(dataPoint.HasMin()) +
+		fixed64FieldSize(dataPoint.HasMax()) +
+		fixed64FieldSize(dataPoint.ZeroThreshold() != 0)
+	return size
+}
+
+func bucketsSize(buckets pmetric.ExponentialHistogramDataPointBuckets) int {
+	size := varintFieldSize(protowire.EncodeZigZag(int64(buckets.Offset())))
+	if buckets.BucketCounts().Len() > 0 {
+		counts := 0
+		for i := 0; i < buckets.BucketCounts().Len(); i++ {
+			counts += protowire.SizeVarint(buckets.BucketCounts().At(i))
+		}
+		size += messageFieldSize(counts)
+	}
+	return size
+}
+
+func summaryDataPointSize(dataPoint pmetric.SummaryDataPoint) int {
+	return messageFieldSize(summaryDataPointFieldsSize(dataPoint))
+}
+
+func summaryDataPointFieldsSize(dataPoint pmetric.SummaryDataPoint) int {
+	size := attributesSize(dataPoint.Attributes()) +
+		fixed64FieldSize(dataPoint.StartTimestamp() != 0) +
+		fixed64FieldSize(dataPoint.Timestamp() != 0) +
+		fixed64FieldSize(dataPoint.Count() != 0) +
+		fixed64FieldSize(dataPoint.Sum() != 0) +
+		varintFieldSize(uint64(dataPoint.Flags()))
+	for i := 0; i < dataPoint.QuantileValues().Len(); i++ {
+		quantile := dataPoint.QuantileValues().At(i)
+		size += messageFieldSize(fixed64FieldSize(quantile.Quantile() != 0) + fixed64FieldSize(quantile.Value() != 0))
+	}
+	return size
+}
+
+func exemplarsSize(exemplars pmetric.ExemplarSlice) int {
+	size := 0
+	for i := 0; i < exemplars.Len(); i++ {
+		exemplar := exemplars.At(i)
+		size += messageFieldSize(attributesSize(exemplar.FilteredAttributes()) +
+			fixed64FieldSize(exemplar.Timestamp() != 0) +
+			fixed64FieldSize(exemplar.ValueType() != pmetric.ExemplarValueTypeEmpty) +
+			idFieldSize(exemplar.SpanID().IsEmpty(), len(exemplar.SpanID())) +
+			idFieldSize(exemplar.TraceID().IsEmpty(), len(exemplar.TraceID())))
+	}
+	return size
+}
+
+// attributesSize returns the size of the key-value fields of an attribute map.
+func attributesSize(attributes pcommon.Map) int {
+	size := 0
+	attributes.Range(func(key string, value pcommon.Value) bool {
+		size += messageFieldSize(stringFieldSize(key) + messageFieldSize(valueSize(value)))
+		return true
+	})
+	return size
+}
+
+// valueSize returns the size of the fields of a value, without its own tag and length prefix. Strings, bytes, slices
+// and maps are encoded even when they are empty.
+func valueSize(value pcommon.Value) int {
+	switch value.Type() {
+	case pcommon.ValueTypeStr:
+		return messageFieldSize(len(value.Str()))
+	case pcommon.ValueTypeBool:
+		return 2
+	case pcommon.ValueTypeInt:
+		return 1 + protowire.SizeVarint(uint64(value.Int()))
+	case pcommon.ValueTypeDouble:
+		return 1 + 8
+	case pcommon.ValueTypeBytes:
+		return messageFieldSize(value.Bytes().Len())
+	case pcommon.ValueTypeSlice:
+		size := 0
+		for i := 0; i < value.Slice().Len(); i++ {
+			size += messageFieldSize(valueSize(value.Slice().At(i)))
+		}
+		return messageFieldSize(size)
+	case pcommon.ValueTypeMap:
+		return messageFieldSize(attributesSize(value.Map()))
+	}
+	return 0
+}
+
+// messageFieldSize returns the size of a length-delimited field, such as a message or string, of the given length.
+func messageFieldSize(length int) int {
+	return protowire.SizeTag(1) + protowire.SizeVarint(uint64(length)) + length
+}
+
+// stringFieldSize returns the size of a string field, which is left out when it is empty.
+func stringFieldSize(value string) int {
+	if value == "" {
+		return 0
+	}
+	return messageFieldSize(len(value))
+}
+
+// varintFieldSize returns the size of a varint field, which is left out when it is zero.
+func varintFieldSize(value uint64) int {
+	if value == 0 {
+		return 0
+	}
+	return protowire.SizeTag(1) + protowire.SizeVarint(value)
+}
+
+func fixed64FieldSize(present bool) int {
+	if !present {
+		return 0
+	}
+	return protowire.SizeTag(1) + 8
+}
+
+func fixed32FieldSize(present bool) int {
+	if !present {
+		return 0
+	}
+	return protowire.SizeTag(1) + 4
+}
+
+// packedFixed64FieldSize returns the size of a packed repeated field of n fixed64 or double values.
+func packedFixed64FieldSize(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return messageFieldSize(n * 8)
+}
+
+func boolFieldSize(value bool) int {
+	if !value {
+		return 0
+	}
+	return protowire.SizeTag(1) + 1
+}
+
+// packedInt32FieldSize returns the size of a packed repeated varint field, which is left out when it is empty.
+func packedInt32FieldSize(values pcommon.Int32Slice) int {
+	if values.Len() == 0 {
+		return 0
+	}
+	size := 0
+	for i := 0; i < values.Len(); i++ {
+		size += protowire.SizeVarint(uint64(int64(values.At(i))))
+	}
+	return messageFieldSize(size)
+}
+
+func packedInt64FieldSize(values pcommon.Int64Slice) int {
+	if values.Len() == 0 {
+		return 0
+	}
+	size := 0
+	for i := 0; i < values.Len(); i++ {
+		size += protowire.SizeVarint(uint64(values.At(i)))
+	}
+	return messageFieldSize(size)
+}
+
+func packedUint64FieldSize(values pcommon.UInt64Slice) int {
+	if values.Len() == 0 {
+		return 0
+	}
+	size := 0
+	for i := 0; i < values.Len(); i++ {
+		size += protowire.SizeVarint(values.At(i))
+	}
+	return messageFieldSize(size)
+}
+
+// idFieldSize returns the size of a trace or span ID field, which is encoded with an empty value when it is not set.
+func idFieldSize(empty bool, length int) int {
+	if empty {
+		return messageFieldSize(0)
+	}
+	return messageFieldSize(length)
+}
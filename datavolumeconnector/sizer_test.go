@@ -0,0 +1,709 @@
+package datavolumeconnector
+
+import (
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pprofile"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/encoding/protowire"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	plogSizer     = plog.ProtoMarshaler{}
+	ptraceSizer   = ptrace.ProtoMarshaler{}
+	pmetricSizer  = pmetric.ProtoMarshaler{}
+	pprofileSizer = pprofile.ProtoMarshaler{}
+)
+
+// The sizes used to be measured by copying resources, scopes and records into otherwise empty envelopes, measuring the
+// whole payload with the marshaler and peeling the envelope off again. That implementation is kept here as the
+// reference the direct sizes have to match.
+
+func copiedResourceLogsSize(resourceLogs plog.ResourceLogs) int {
+	isolatedPlog := plog.NewLogs()
+	resourceLogs.CopyTo(isolatedPlog.ResourceLogs().AppendEmpty())
+	return plogSizer.LogsSize(isolatedPlog)
+}
+
+func copiedResourceSpansSize(resourceSpans ptrace.ResourceSpans) int {
+	isolatedPtraces := ptrace.NewTraces()
+	resourceSpans.CopyTo(isolatedPtraces.ResourceSpans().AppendEmpty())
+	return ptraceSizer.TracesSize(isolatedPtraces)
+}
+
+func copiedResourceMetricsSize(resourceMetrics pmetric.ResourceMetrics) int {
+	isolatedPmetrics := pmetric.NewMetrics()
+	resourceMetrics.CopyTo(isolatedPmetrics.ResourceMetrics().AppendEmpty())
+	return pmetricSizer.MetricsSize(isolatedPmetrics)
+}
+
+func copiedResourceProfilesSize(resourceProfiles pprofile.ResourceProfiles) int {
+	isolatedPprofiles := pprofile.NewProfiles()
+	resourceProfiles.CopyTo(isolatedPprofiles.ResourceProfiles().AppendEmpty())
+	return pprofileSizer.ProfilesSize(isolatedPprofiles)
+}
+
+func copiedScopeLogsSize(scopeLogs plog.ScopeLogs) int {
+	isolatedPlog := plog.NewLogs()
+	scopeLogs.CopyTo(isolatedPlog.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty())
+	return resourceContentSize(plogSizer.LogsSize(isolatedPlog))
+}
+
+func copiedScopeSpansSize(scopeSpans ptrace.ScopeSpans) int {
+	isolatedPtraces := ptrace.NewTraces()
+	scopeSpans.CopyTo(isolatedPtraces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty())
+	return resourceContentSize(ptraceSizer.TracesSize(isolatedPtraces))
+}
+
+func copiedScopeMetricsSize(scopeMetrics pmetric.ScopeMetrics) int {
+	isolatedPmetrics := pmetric.NewMetrics()
+	scopeMetrics.CopyTo(isolatedPmetrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty())
+	return resourceContentSize(pmetricSizer.MetricsSize(isolatedPmetrics))
+}
+
+func copiedScopeProfilesSize(scopeProfiles pprofile.ScopeProfiles) int {
+	isolatedPprofiles := pprofile.NewProfiles()
+	scopeProfiles.CopyTo(isolatedPprofiles.ResourceProfiles().AppendEmpty().ScopeProfiles().AppendEmpty())
+	return resourceContentSize(pprofileSizer.ProfilesSize(isolatedPprofiles))
+}
+
+func copiedLogRecordSize(record plog.LogRecord) int {
+	isolatedPlog := plog.NewLogs()
+	record.CopyTo(isolatedPlog.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty())
+	return scopeContentSize(plogSizer.LogsSize(isolatedPlog))
+}
+
+func copiedSpanSize(span ptrace.Span) int {
+	isolatedPtraces := ptrace.NewTraces()
+	span.CopyTo(isolatedPtraces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty())
+	return scopeContentSize(ptraceSizer.TracesSize(isolatedPtraces))
+}
+
+func copiedProfileSize(profile pprofile.Profile) int {
+	isolatedPprofiles := pprofile.NewProfiles()
+	profile.CopyTo(isolatedPprofiles.ResourceProfiles().AppendEmpty().ScopeProfiles().AppendEmpty().Profiles().AppendEmpty())
+	return scopeContentSize(pprofileSizer.ProfilesSize(isolatedPprofiles))
+}
+
+// copiedDataPointSizes returns the size of every data point of a metric, measured as the only data point of an
+// otherwise empty, unnamed metric of the same type.
+func copiedDataPointSizes(metric pmetric.Metric) []int {
+	var sizes []int
+	measure := func(copyTo func(metric pmetric.Metric)) {
+		isolatedPmetrics := pmetric.NewMetrics()
+		copyTo(isolatedPmetrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty())
+		sizes = append(sizes, metricDataSize(pmetricSizer.MetricsSize(isolatedPmetrics)))
+	}
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < metric.Gauge().DataPoints().Len(); i++ {
+			measure(func(m pmetric.Metric) {
+				metric.Gauge().DataPoints().At(i).CopyTo(m.SetEmptyGauge().DataPoints().AppendEmpty())
+			})
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < metric.Sum().DataPoints().Len(); i++ {
+			measure(func(m pmetric.Metric) {
+				metric.Sum().DataPoints().At(i).CopyTo(m.SetEmptySum().DataPoints().AppendEmpty())
+			})
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < metric.Histogram().DataPoints().Len(); i++ {
+			measure(func(m pmetric.Metric) {
+				metric.Histogram().DataPoints().At(i).CopyTo(m.SetEmptyHistogram().DataPoints().AppendEmpty())
+			})
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < metric.ExponentialHistogram().DataPoints().Len(); i++ {
+			measure(func(m pmetric.Metric) {
+				metric.ExponentialHistogram().DataPoints().At(i).CopyTo(m.SetEmptyExponentialHistogram().DataPoints().AppendEmpty())
+			})
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < metric.Summary().DataPoints().Len(); i++ {
+			measure(func(m pmetric.Metric) {
+				metric.Summary().DataPoints().At(i).CopyTo(m.SetEmptySummary().DataPoints().AppendEmpty())
+			})
+		}
+	}
+	return sizes
+}
+
+func dataPointSizes(metric pmetric.Metric) []int {
+	var sizes []int
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < metric.Gauge().DataPoints().Len(); i++ {
+			sizes = append(sizes, numberDataPointSize(metric.Gauge().DataPoints().At(i)))
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < metric.Sum().DataPoints().Len(); i++ {
+			sizes = append(sizes, numberDataPointSize(metric.Sum().DataPoints().At(i)))
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < metric.Histogram().DataPoints().Len(); i++ {
+			sizes = append(sizes, histogramDataPointSize(metric.Histogram().DataPoints().At(i)))
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < metric.ExponentialHistogram().DataPoints().Len(); i++ {
+			sizes = append(sizes, exponentialHistogramDataPointSize(metric.ExponentialHistogram().DataPoints().At(i)))
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < metric.Summary().DataPoints().Len(); i++ {
+			sizes = append(sizes, summaryDataPointSize(metric.Summary().DataPoints().At(i)))
+		}
+	}
+	return sizes
+}
+
+// resourceContentSize takes the size of a payload holding one resource with an empty resource, and returns the size of
+// everything in that resource besides the resource itself.
+func resourceContentSize(payloadSize int) int {
+	return nestedSize(payloadSize, 0) - emptyMessageFieldSize
+}
+
+// scopeContentSize takes the size of a payload holding one resource with an empty resource and one scope with an empty
+// scope, and returns the size of everything in that scope besides the scope itself.
+func scopeContentSize(payloadSize int) int {
+	resourceSize := nestedSize(payloadSize, 0)
+	scopeSize := nestedSize(resourceSize, emptyMessageFieldSize)
+	return scopeSize - emptyMessageFieldSize
+}
+
+// metricDataSize takes the size of a payload holding a single unnamed metric with a single data point, and returns the
+// size that data point occupies in the metric's data.
+func metricDataSize(payloadSize int) int {
+	metricSize := nestedSize(scopeContentSize(payloadSize), 0)
+	return nestedSize(metricSize, 0)
+}
+
+// nestedSize returns the size of the only length-delimited field of a message of the given size, where prefix is
+// the size of all other fields of that message.
+func nestedSize(messageSize, prefix int) int {
+	fieldSize := messageSize - prefix - protowire.SizeTag(1)
+	for lengthSize := 1; lengthSize <= protowire.SizeVarint(uint64(fieldSize)); lengthSize++ {
+		size := fieldSize - lengthSize
+		if size >= 0 && protowire.SizeVarint(uint64(size)) == lengthSize {
+			return size
+		}
+	}
+	return 0
+}
+
+func assertLogsSizes(t *testing.T, logs plog.Logs) {
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		resourceLogs := logs.ResourceLogs().At(i)
+		require.Equal(t, copiedResourceLogsSize(resourceLogs), resourceLogsSize(resourceLogs))
+		for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
+			scopeLogs := resourceLogs.ScopeLogs().At(j)
+			require.Equal(t, copiedScopeLogsSize(scopeLogs), scopeLogsSize(scopeLogs))
+			for k := 0; k < scopeLogs.LogRecords().Len(); k++ {
+				require.Equal(t, copiedLogRecordSize(scopeLogs.LogRecords().At(k)), logRecordSize(scopeLogs.LogRecords().At(k)))
+			}
+		}
+	}
+}
+
+func assertTracesSizes(t *testing.T, traces ptrace.Traces) {
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		resourceSpans := traces.ResourceSpans().At(i)
+		require.Equal(t, copiedResourceSpansSize(resourceSpans), resourceSpansSize(resourceSpans))
+		for j := 0; j < resourceSpans.ScopeSpans().Len(); j++ {
+			scopeSpans := resourceSpans.ScopeSpans().At(j)
+			require.Equal(t, copiedScopeSpansSize(scopeSpans), scopeSpansSize(scopeSpans))
+			for k := 0; k < scopeSpans.Spans().Len(); k++ {
+				require.Equal(t, copiedSpanSize(scopeSpans.Spans().At(k)), spanSize(scopeSpans.Spans().At(k)))
+			}
+		}
+	}
+}
+
+func assertMetricsSizes(t *testing.T, metrics pmetric.Metrics) {
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		resourceMetrics := metrics.ResourceMetrics().At(i)
+		require.Equal(t, copiedResourceMetricsSize(resourceMetrics), resourceMetricsSize(resourceMetrics))
+		for j := 0; j < resourceMetrics.ScopeMetrics().Len(); j++ {
+			scopeMetrics := resourceMetrics.ScopeMetrics().At(j)
+			require.Equal(t, copiedScopeMetricsSize(scopeMetrics), scopeMetricsSize(scopeMetrics))
+			for k := 0; k < scopeMetrics.Metrics().Len(); k++ {
+				metric := scopeMetrics.Metrics().At(k)
+				require.Equal(t, copiedDataPointSizes(metric), dataPointSizes(metric), metric.Name())
+			}
+		}
+	}
+}
+
+func assertProfilesSizes(t *testing.T, profiles pprofile.Profiles) {
+	for i := 0; i < profiles.ResourceProfiles().Len(); i++ {
+		resourceProfiles := profiles.ResourceProfiles().At(i)
+		require.Equal(t, copiedResourceProfilesSize(resourceProfiles), resourceProfilesSize(resourceProfiles))
+		for j := 0; j < resourceProfiles.ScopeProfiles().Len(); j++ {
+			scopeProfiles := resourceProfiles.ScopeProfiles().At(j)
+			require.Equal(t, copiedScopeProfilesSize(scopeProfiles), scopeProfilesSize(scopeProfiles))
+			for k := 0; k < scopeProfiles.Profiles().Len(); k++ {
+				require.Equal(t, copiedProfileSize(scopeProfiles.Profiles().At(k)), profileSize(scopeProfiles.Profiles().At(k)))
+			}
+		}
+	}
+}
+
+func TestSizesMatchCopiedSizes(t *testing.T) {
+	for _, input := range []string{"input_logs.yaml", "input_scope_logs.yaml", "input_nested_logs.yaml"} {
+		t.Run(input, func(t *testing.T) {
+			testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", input))
+			require.NoError(t, err)
+			assertLogsSizes(t, testLogs)
+		})
+	}
+	t.Run("input_traces.yaml", func(t *testing.T) {
+		testTraces, err := golden.ReadTraces(filepath.Join("testdata", "traces", "input_traces.yaml"))
+		require.NoError(t, err)
+		assertTracesSizes(t, testTraces)
+	})
+	t.Run("input_metrics.yaml", func(t *testing.T) {
+		testMetrics, err := golden.ReadMetrics(filepath.Join("testdata", "metrics", "input_metrics.yaml"))
+		require.NoError(t, err)
+		assertMetricsSizes(t, testMetrics)
+	})
+	t.Run("input_profiles.yaml", func(t *testing.T) {
+		testProfiles, err := readProfiles(filepath.Join("testdata", "profiles", "input_profiles.yaml"))
+		require.NoError(t, err)
+		assertProfilesSizes(t, testProfiles)
+	})
+}
+
+func putEdgeCaseAttributes(attributes pcommon.Map) {
+	attributes.PutStr("", "")
+	attributes.PutStr("str", "value")
+	attributes.PutInt("negative", -1)
+	attributes.PutInt("large", math.MaxInt64)
+	attributes.PutBool("false", false)
+	attributes.PutDouble("zero", 0)
+	attributes.PutEmpty("empty")
+	attributes.PutEmptyBytes("empty_bytes")
+	attributes.PutEmptyBytes("bytes").FromRaw([]byte{0, 1, 2})
+	attributes.PutEmptySlice("empty_slice")
+	slice := attributes.PutEmptySlice("slice")
+	slice.AppendEmpty()
+	slice.AppendEmpty().SetStr("")
+	slice.AppendEmpty().SetEmptyMap().PutStr("nested", string(make([]byte, 200)))
+	attributes.PutEmptyMap("empty_map")
+	attributes.PutEmptyMap("map").PutEmptySlice("slice").AppendEmpty().SetInt(300)
+}
+
+func TestLogsSizesEdgeCases(t *testing.T) {
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty()
+	resourceLogs := logs.ResourceLogs().AppendEmpty()
+	resourceLogs.SetSchemaUrl("https://opentelemetry.io/schemas/1.26.0")
+	putEdgeCaseAttributes(resourceLogs.Resource().Attributes())
+	resourceLogs.Resource().SetDroppedAttributesCount(3)
+	resourceLogs.ScopeLogs().AppendEmpty()
+	scopeLogs := resourceLogs.ScopeLogs().AppendEmpty()
+	scopeLogs.SetSchemaUrl("schema")
+	scopeLogs.Scope().SetName("scope")
+	scopeLogs.Scope().SetVersion("v1")
+	scopeLogs.Scope().SetDroppedAttributesCount(1 << 20)
+	putEdgeCaseAttributes(scopeLogs.Scope().Attributes())
+
+	scopeLogs.LogRecords().AppendEmpty()
+	scopeLogs.LogRecords().AppendEmpty().Body().SetStr("")
+	scopeLogs.LogRecords().AppendEmpty().Body().SetEmptyBytes()
+	record := scopeLogs.LogRecords().AppendEmpty()
+	record.SetTimestamp(1)
+	record.SetObservedTimestamp(2)
+	record.SetSeverityNumber(plog.SeverityNumberFatal4)
+	record.SetSeverityText("FATAL")
+	record.SetFlags(plog.DefaultLogRecordFlags.WithIsSampled(true))
+	record.SetTraceID(pcommon.TraceID{1})
+	record.SetSpanID(pcommon.SpanID{1})
+	record.SetEventName("event")
+	record.SetDroppedAttributesCount(1)
+	record.Body().SetEmptyMap().PutStr("message", string(make([]byte, 20000)))
+	putEdgeCaseAttributes(record.Attributes())
+	negative := scopeLogs.LogRecords().AppendEmpty()
+	negative.SetSeverityNumber(-1)
+	negative.Body().SetDouble(math.Inf(-1))
+
+	assertLogsSizes(t, logs)
+}
+
+func TestTracesSizesEdgeCases(t *testing.T) {
+	traces := ptrace.NewTraces()
+	resourceSpans := traces.ResourceSpans().AppendEmpty()
+	resourceSpans.SetSchemaUrl("schema")
+	putEdgeCaseAttributes(resourceSpans.Resource().Attributes())
+	scopeSpans := resourceSpans.ScopeSpans().AppendEmpty()
+	scopeSpans.Scope().SetName("scope")
+
+	scopeSpans.Spans().AppendEmpty()
+	span := scopeSpans.Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID{1})
+	span.SetSpanID(pcommon.SpanID{2})
+	span.SetParentSpanID(pcommon.SpanID{3})
+	span.TraceState().FromRaw("key=value")
+	span.SetName("span")
+	span.SetKind(ptrace.SpanKindConsumer)
+	span.SetStartTimestamp(1)
+	span.SetEndTimestamp(2)
+	span.SetFlags(1)
+	span.SetDroppedAttributesCount(1)
+	span.SetDroppedEventsCount(2)
+	span.SetDroppedLinksCount(300)
+	span.Status().SetCode(ptrace.StatusCodeError)
+	span.Status().SetMessage("failed")
+	putEdgeCaseAttributes(span.Attributes())
+	span.Events().AppendEmpty()
+	event := span.Events().AppendEmpty()
+	event.SetName("event")
+	event.SetTimestamp(3)
+	event.SetDroppedAttributesCount(1)
+	putEdgeCaseAttributes(event.Attributes())
+	span.Links().AppendEmpty()
+	link := span.Links().AppendEmpty()
+	link.SetTraceID(pcommon.TraceID{4})
+	link.SetSpanID(pcommon.SpanID{5})
+	link.TraceState().FromRaw("key=value")
+	link.SetFlags(1 << 31)
+	link.SetDroppedAttributesCount(1)
+	putEdgeCaseAttributes(link.Attributes())
+	flagsOnly := scopeSpans.Spans().AppendEmpty()
+	flagsOnly.SetFlags(math.MaxUint32)
+	flagsOnly.Status().SetCode(ptrace.StatusCodeOk)
+
+	assertTracesSizes(t, traces)
+}
+
+func putEdgeCaseExemplars(exemplars pmetric.ExemplarSlice) {
+	exemplars.AppendEmpty()
+	exemplars.AppendEmpty().SetDoubleValue(0)
+	exemplar := exemplars.AppendEmpty()
+	exemplar.SetIntValue(-1)
+	exemplar.SetTimestamp(1)
+	exemplar.SetTraceID(pcommon.TraceID{1})
+	exemplar.SetSpanID(pcommon.SpanID{1})
+	putEdgeCaseAttributes(exemplar.FilteredAttributes())
+}
+
+func TestMetricsSizesEdgeCases(t *testing.T) {
+	metrics := pmetric.NewMetrics()
+	resourceMetrics := metrics.ResourceMetrics().AppendEmpty()
+	resourceMetrics.SetSchemaUrl("schema")
+	putEdgeCaseAttributes(resourceMetrics.Resource().Attributes())
+	scopeMetrics := resourceMetrics.ScopeMetrics().AppendEmpty()
+	scopeMetrics.SetSchemaUrl("schema")
+
+	scopeMetrics.Metrics().AppendEmpty()
+	scopeMetrics.Metrics().AppendEmpty().SetEmptyGauge()
+	gauge := scopeMetrics.Metrics().AppendEmpty()
+	gauge.SetName("gauge")
+	gauge.SetDescription("description")
+	gauge.SetUnit("By")
+	putEdgeCaseAttributes(gauge.Metadata())
+	gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dataPoint := gauge.Gauge().DataPoints().AppendEmpty()
+	dataPoint.SetIntValue(0)
+	dataPoint.SetFlags(pmetric.DefaultDataPointFlags.WithNoRecordedValue(true))
+	dataPoint.SetStartTimestamp(1)
+	dataPoint.SetTimestamp(2)
+	putEdgeCaseAttributes(dataPoint.Attributes())
+	putEdgeCaseExemplars(dataPoint.Exemplars())
+
+	sum := scopeMetrics.Metrics().AppendEmpty().SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	sum.DataPoints().AppendEmpty().SetDoubleValue(math.NaN())
+
+	histogram := scopeMetrics.Metrics().AppendEmpty().SetEmptyHistogram()
+	histogram.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	histogram.DataPoints().AppendEmpty()
+	histogramDataPoint := histogram.DataPoints().AppendEmpty()
+	histogramDataPoint.SetCount(3)
+	histogramDataPoint.SetSum(0)
+	histogramDataPoint.SetMin(0)
+	histogramDataPoint.SetMax(-1)
+	histogramDataPoint.BucketCounts().FromRaw([]uint64{0, 1, 2})
+	histogramDataPoint.ExplicitBounds().FromRaw([]float64{0, 1})
+	putEdgeCaseExemplars(histogramDataPoint.Exemplars())
+
+	exponentialHistogram := scopeMetrics.Metrics().AppendEmpty().SetEmptyExponentialHistogram()
+	exponentialHistogram.DataPoints().AppendEmpty()
+	exponentialDataPoint := exponentialHistogram.DataPoints().AppendEmpty()
+	exponentialDataPoint.SetScale(-4)
+	exponentialDataPoint.SetZeroCount(1)
+	exponentialDataPoint.SetZeroThreshold(1e-9)
+	exponentialDataPoint.SetCount(300)
+	exponentialDataPoint.SetSum(1)
+	exponentialDataPoint.SetMin(1)
+	exponentialDataPoint.SetMax(1)
+	exponentialDataPoint.SetFlags(1)
+	exponentialDataPoint.Positive().SetOffset(-1000)
+	exponentialDataPoint.Positive().BucketCounts().FromRaw([]uint64{0, 1, 200, 1 << 40})
+	exponentialDataPoint.Negative().SetOffset(math.MaxInt32)
+	putEdgeCaseAttributes(exponentialDataPoint.Attributes())
+	putEdgeCaseExemplars(exponentialDataPoint.Exemplars())
+
+	summary := scopeMetrics.Metrics().AppendEmpty().SetEmptySummary()
+	summary.DataPoints().AppendEmpty()
+	summaryDataPoint := summary.DataPoints().AppendEmpty()
+	summaryDataPoint.SetCount(1)
+	summaryDataPoint.SetSum(-1)
+	summaryDataPoint.QuantileValues().AppendEmpty()
+	quantile := summaryDataPoint.QuantileValues().AppendEmpty()
+	quantile.SetQuantile(0.99)
+	quantile.SetValue(1)
+
+	assertMetricsSizes(t, metrics)
+}
+
+// The scopes of a resource account for all of its bytes besides the resource itself, so measuring them one by one has
+// to add up to the size of a payload holding all of them in a single empty resource.
+
+func TestProfilesSizesEdgeCases(t *testing.T) {
+	profiles := pprofile.NewProfiles()
+	profiles.ResourceProfiles().AppendEmpty()
+	resourceProfiles := profiles.ResourceProfiles().AppendEmpty()
+	resourceProfiles.SetSchemaUrl("schema")
+	putEdgeCaseAttributes(resourceProfiles.Resource().Attributes())
+	resourceProfiles.ScopeProfiles().AppendEmpty()
+	scopeProfiles := resourceProfiles.ScopeProfiles().AppendEmpty()
+	scopeProfiles.Scope().SetName("scope")
+	putEdgeCaseAttributes(scopeProfiles.Scope().Attributes())
+
+	scopeProfiles.Profiles().AppendEmpty()
+	profile := scopeProfiles.Profiles().AppendEmpty()
+	profile.SetProfileID(pprofile.ProfileID{1})
+	profile.SetTime(1)
+	profile.SetDuration(math.MaxInt64)
+	profile.SetPeriod(-1)
+	profile.SetDefaultSampleTypeStrindex(-1)
+	profile.SetDroppedAttributesCount(1 << 20)
+	profile.SetOriginalPayloadFormat("pprof")
+	profile.OriginalPayload().FromRaw(make([]byte, 300))
+	profile.StringTable().Append("", "cpu", string(make([]byte, 200)))
+	profile.CommentStrindices().Append(0, 2)
+	profile.LocationIndices().Append(0, 1<<30)
+	profile.AttributeIndices().Append(0, 1)
+	profile.PeriodType().SetTypeStrindex(1)
+	sampleType := profile.SampleType().AppendEmpty()
+	sampleType.SetUnitStrindex(-1)
+	// The aggregation temporality enum is internal to pdata, so cumulative is set by its number.
+	sampleType.SetAggregationTemporality(2)
+	profile.SampleType().AppendEmpty()
+	putEdgeCaseAttributes(profile.AttributeTable().AppendEmpty().Value().SetEmptyMap())
+	profile.AttributeTable().AppendEmpty().SetKey("key")
+	unit := profile.AttributeUnits().AppendEmpty()
+	unit.SetAttributeKeyStrindex(1)
+	unit.SetUnitStrindex(2)
+	link := profile.LinkTable().AppendEmpty()
+	link.SetTraceID(pcommon.TraceID{1})
+	link.SetSpanID(pcommon.SpanID{1})
+	profile.LinkTable().AppendEmpty()
+	mapping := profile.MappingTable().AppendEmpty()
+	mapping.SetMemoryStart(math.MaxUint64)
+	mapping.SetFileOffset(1)
+	mapping.SetHasFunctions(true)
+	mapping.SetHasInlineFrames(true)
+	mapping.AttributeIndices().Append(1)
+	profile.MappingTable().AppendEmpty()
+	location := profile.LocationTable().AppendEmpty()
+	location.SetMappingIndex(0)
+	location.SetAddress(4096)
+	location.SetIsFolded(true)
+	location.AttributeIndices().Append(0)
+	line := location.Line().AppendEmpty()
+	line.SetFunctionIndex(1)
+	line.SetLine(-1)
+	location.Line().AppendEmpty()
+	profile.LocationTable().AppendEmpty()
+	function := profile.FunctionTable().AppendEmpty()
+	function.SetNameStrindex(1)
+	function.SetStartLine(-1)
+	profile.FunctionTable().AppendEmpty()
+	profile.Sample().AppendEmpty()
+	sample := profile.Sample().AppendEmpty()
+	sample.SetLocationsStartIndex(-1)
+	sample.SetLocationsLength(2)
+	sample.Value().Append(-1, 0, math.MaxInt64)
+	sample.AttributeIndices().Append(1)
+	sample.TimestampsUnixNano().Append(0, math.MaxUint64)
+
+	assertProfilesSizes(t, profiles)
+}
+
+func TestScopeLogsSize(t *testing.T) {
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_scope_logs.yaml"))
+	require.NoError(t, err)
+
+	combined := plog.NewLogs()
+	combinedScopes := combined.ResourceLogs().AppendEmpty().ScopeLogs()
+	total := 0
+	for i := 0; i < testLogs.ResourceLogs().Len(); i++ {
+		scopeLogs := testLogs.ResourceLogs().At(i).ScopeLogs()
+		for j := 0; j < scopeLogs.Len(); j++ {
+			total += scopeLogsSize(scopeLogs.At(j))
+			scopeLogs.At(j).CopyTo(combinedScopes.AppendEmpty())
+		}
+	}
+	assert.Equal(t, resourceContentSize(plogSizer.LogsSize(combined)), total)
+}
+
+// The records of a scope account for all of its bytes besides the scope itself, so measuring them one by one has to
+// add up to the size of a payload holding all of them in a single empty resource and scope.
+
+func TestLogRecordSize(t *testing.T) {
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_logs.yaml"))
+	require.NoError(t, err)
+
+	combined := plog.NewLogs()
+	combinedRecords := combined.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	total := 0
+	for i := 0; i < testLogs.ResourceLogs().Len(); i++ {
+		scopeLogs := testLogs.ResourceLogs().At(i).ScopeLogs()
+		for j := 0; j < scopeLogs.Len(); j++ {
+			logRecords := scopeLogs.At(j).LogRecords()
+			for k := 0; k < logRecords.Len(); k++ {
+				total += logRecordSize(logRecords.At(k))
+				logRecords.At(k).CopyTo(combinedRecords.AppendEmpty())
+			}
+		}
+	}
+	assert.Equal(t, scopeContentSize(plogSizer.LogsSize(combined)), total)
+}
+
+func TestSpanSize(t *testing.T) {
+	testTraces, err := golden.ReadTraces(filepath.Join("testdata", "traces", "input_traces.yaml"))
+	require.NoError(t, err)
+
+	combined := ptrace.NewTraces()
+	combinedSpans := combined.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	total := 0
+	for i := 0; i < testTraces.ResourceSpans().Len(); i++ {
+		scopeSpans := testTraces.ResourceSpans().At(i).ScopeSpans()
+		for j := 0; j < scopeSpans.Len(); j++ {
+			spans := scopeSpans.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				total += spanSize(spans.At(k))
+				spans.At(k).CopyTo(combinedSpans.AppendEmpty())
+			}
+		}
+	}
+	assert.Equal(t, scopeContentSize(ptraceSizer.TracesSize(combined)), total)
+}
+
+func TestDataPointSize(t *testing.T) {
+	testMetrics, err := golden.ReadMetrics(filepath.Join("testdata", "metrics", "input_metrics.yaml"))
+	require.NoError(t, err)
+
+	resourceMetrics := testMetrics.ResourceMetrics()
+	for i := 0; i < resourceMetrics.Len(); i++ {
+		scopeMetrics := resourceMetrics.At(i).ScopeMetrics()
+		for j := 0; j < scopeMetrics.Len(); j++ {
+			metrics := scopeMetrics.At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				metric := metrics.At(k)
+
+				isolatedPmetrics := pmetric.NewMetrics()
+				isolatedMetric := isolatedPmetrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+				switch metric.Type() {
+				case pmetric.MetricTypeGauge:
+					metric.Gauge().DataPoints().CopyTo(isolatedMetric.SetEmptyGauge().DataPoints())
+				case pmetric.MetricTypeSum:
+					metric.Sum().DataPoints().CopyTo(isolatedMetric.SetEmptySum().DataPoints())
+				case pmetric.MetricTypeHistogram:
+					metric.Histogram().DataPoints().CopyTo(isolatedMetric.SetEmptyHistogram().DataPoints())
+				case pmetric.MetricTypeSummary:
+					metric.Summary().DataPoints().CopyTo(isolatedMetric.SetEmptySummary().DataPoints())
+				}
+				total := 0
+				for _, size := range dataPointSizes(metric) {
+					total += size
+				}
+				assert.Equal(t, metricDataSize(pmetricSizer.MetricsSize(isolatedPmetrics)), total, metric.Name())
+			}
+		}
+	}
+}
+
+func BenchmarkResourceLogsSize(b *testing.B) {
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_logs.yaml"))
+	require.NoError(b, err)
+	resourceLogs := testLogs.ResourceLogs().At(0)
+
+	b.Run("copied", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copiedResourceLogsSize(resourceLogs)
+		}
+	})
+	b.Run("direct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resourceLogsSize(resourceLogs)
+		}
+	})
+}
+
+func BenchmarkLogRecordSize(b *testing.B) {
+	testLogs, err := golden.ReadLogs(filepath.Join("testdata", "logs", "input_logs.yaml"))
+	require.NoError(b, err)
+	logRecord := testLogs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+
+	b.Run("copied", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copiedLogRecordSize(logRecord)
+		}
+	})
+	b.Run("direct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logRecordSize(logRecord)
+		}
+	})
+}
+
+func BenchmarkResourceSpansSize(b *testing.B) {
+	testTraces, err := golden.ReadTraces(filepath.Join("testdata", "traces", "input_traces.yaml"))
+	require.NoError(b, err)
+	resourceSpans := testTraces.ResourceSpans().At(0)
+
+	b.Run("copied", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copiedResourceSpansSize(resourceSpans)
+		}
+	})
+	b.Run("direct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resourceSpansSize(resourceSpans)
+		}
+	})
+}
+
+func BenchmarkResourceMetricsSize(b *testing.B) {
+	testMetrics, err := golden.ReadMetrics(filepath.Join("testdata", "metrics", "input_metrics.yaml"))
+	require.NoError(b, err)
+	resourceMetrics := testMetrics.ResourceMetrics().At(0)
+
+	b.Run("copied", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copiedResourceMetricsSize(resourceMetrics)
+		}
+	})
+	b.Run("direct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resourceMetricsSize(resourceMetrics)
+		}
+	})
+}
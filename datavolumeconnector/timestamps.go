@@ -0,0 +1,101 @@
+package datavolumeconnector
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"time"
+)
+
+const (
+	defaultMaxClockSkew  = time.Minute
+	defaultMaxOutOfOrder = 5 * time.Minute
+
+	timestampAnomalyAttributeKey = "otel.timestamp.anomaly"
+)
+
+// defaultLagBuckets are the explicit bucket boundaries of the lag histogram, in milliseconds, if none are configured.
+var defaultLagBuckets = []float64{100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 300000, 900000, 3600000}
+
+type timestampAnomaly int
+
+const (
+	anomalyZero timestampAnomaly = iota
+	anomalyFuture
+	anomalyOutOfOrder
+	anomalyNone
+)
+
+var anomalyNames = [...]string{
+	anomalyZero:       "zero",
+	anomalyFuture:     "future",
+	anomalyOutOfOrder: "out_of_order",
+}
+
+// timestampAnomalies counts records per kind of timestamp anomaly.
+type timestampAnomalies [anomalyNone]int64
+
+func (a *timestampAnomalies) add(other timestampAnomalies) {
+	for i, count := range other {
+		a[i] += count
+	}
+}
+
+// recordTimestamp is what the timestamp of a record says about its ingestion.
+type recordTimestamp struct {
+	// lag is how long ago the record was produced, in milliseconds, only valid if there is no zero or future anomaly
+	lag     int
+	anomaly timestampAnomaly
+}
+
+// timestampChecker checks the timestamps of the records of a single resource against the time its batch was received.
+type timestampChecker struct {
+	received      pcommon.Timestamp
+	maxClockSkew  pcommon.Timestamp
+	maxOutOfOrder pcommon.Timestamp
+	// newest is the newest timestamp of the records checked so far
+	newest pcommon.Timestamp
+}
+
+func (c *connectorImp) newTimestampChecker(b *batch) *timestampChecker {
+	return &timestampChecker{
+		received:      b.received,
+		maxClockSkew:  pcommon.Timestamp(c.config.MaxClockSkew),
+		maxOutOfOrder: pcommon.Timestamp(c.config.MaxOutOfOrder),
+	}
+}
+
+// check returns the lag and anomaly of a record with the given timestamp. A record is out of order when its timestamp
+// is more than the allowed amount older than the newest timestamp before it.
+func (t *timestampChecker) check(timestamp pcommon.Timestamp) recordTimestamp {
+	if timestamp == 0 {
+		return recordTimestamp{anomaly: anomalyZero}
+	}
+	if timestamp > t.received+t.maxClockSkew {
+		return recordTimestamp{anomaly: anomalyFuture}
+	}
+
+	checked := recordTimestamp{anomaly: anomalyNone}
+	if timestamp < t.received {
+		checked.lag = int(time.Duration(t.received-timestamp) / time.Millisecond)
+	}
+	if t.newest > t.maxOutOfOrder && timestamp < t.newest-t.maxOutOfOrder {
+		checked.anomaly = anomalyOutOfOrder
+	}
+	t.newest = max(t.newest, timestamp)
+	return checked
+}
+
+// addAnomalyDataPoints adds a data point per kind of anomaly to an anomaly sum. Kinds that no record had are left out.
+func addAnomalyDataPoints(sum pmetric.Sum, labels pcommon.Map, start, timestamp pcommon.Timestamp, anomalies timestampAnomalies) {
+	for anomaly, value := range anomalies {
+		if value == 0 {
+			continue
+		}
+		dataPoint := sum.DataPoints().AppendEmpty()
+		labels.CopyTo(dataPoint.Attributes())
+		dataPoint.Attributes().PutStr(timestampAnomalyAttributeKey, anomalyNames[anomaly])
+		dataPoint.SetStartTimestamp(start)
+		dataPoint.SetTimestamp(timestamp)
+		dataPoint.SetIntValue(value)
+	}
+}
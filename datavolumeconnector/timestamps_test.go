@@ -0,0 +1,29 @@
+package datavolumeconnector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"testing"
+	"time"
+)
+
+func TestTimestampChecker(t *testing.T) {
+	received := time.Unix(3600, 0)
+	checker := &timestampChecker{
+		received:      pcommon.NewTimestampFromTime(received),
+		maxClockSkew:  pcommon.Timestamp(time.Minute),
+		maxOutOfOrder: pcommon.Timestamp(5 * time.Minute),
+	}
+	at := func(offset time.Duration) pcommon.Timestamp {
+		return pcommon.NewTimestampFromTime(received.Add(offset))
+	}
+
+	assert.Equal(t, recordTimestamp{anomaly: anomalyZero}, checker.check(0))
+	assert.Equal(t, recordTimestamp{anomaly: anomalyFuture}, checker.check(at(2*time.Minute)))
+	// timestamps within the clock skew have no lag
+	assert.Equal(t, recordTimestamp{anomaly: anomalyNone}, checker.check(at(30*time.Second)))
+	assert.Equal(t, recordTimestamp{lag: 1500, anomaly: anomalyNone}, checker.check(at(-1500*time.Millisecond)))
+	// the newest timestamp so far is 30s ahead, so 4m30s ago is the oldest timestamp still in order
+	assert.Equal(t, recordTimestamp{lag: 270000, anomaly: anomalyNone}, checker.check(at(-270*time.Second)))
+	assert.Equal(t, recordTimestamp{lag: 360000, anomaly: anomalyOutOfOrder}, checker.check(at(-6*time.Minute)))
+}
@@ -0,0 +1,242 @@
+package datavolumeconnector
+
+import (
+	"fmt"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatautil"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"slices"
+	"strings"
+)
+
+const (
+	countUnitLogRecords           = "log_records"
+	countUnitSpans                = "spans"
+	countUnitSpansEventsLinks     = "spans_events_links"
+	countUnitMetrics              = "metrics"
+	countUnitDataPoints           = "data_points"
+	countUnitSeries               = "series"
+	countUnitDataPointsAndBuckets = "data_points_and_buckets"
+	countUnitSamples              = "samples"
+)
+
+// countUnits are the units the items of each signal can be counted in, with the default first.
+var countUnits = map[string][]string{
+	dataTypeLogsAttributeValue:     {countUnitLogRecords},
+	dataTypeTracesAttributeValue:   {countUnitSpans, countUnitSpansEventsLinks},
+	dataTypeMetricsAttributeValue:  {countUnitMetrics, countUnitDataPoints, countUnitSeries, countUnitDataPointsAndBuckets},
+	dataTypeProfilesAttributeValue: {countUnitSamples},
+}
+
+// countUnit returns the unit the items of a signal are counted in, the default one if unit is empty.
+func countUnit(signal, unit string) (string, error) {
+	units := countUnits[signal]
+	if unit == "" {
+		return units[0], nil
+	}
+	if len(units) == 1 && unit != units[0] {
+		return "", fmt.Errorf("count_unit of %s must be %q, got %q", signal, units[0], unit)
+	}
+	if !slices.Contains(units, unit) {
+		return "", fmt.Errorf("count_unit of %s must be one of %s, got %q", signal, quotedList(units), unit)
+	}
+	return unit, nil
+}
+
+// validCountUnit reports whether unit is a count unit of any signal.
+func validCountUnit(unit string) bool {
+	for _, units := range countUnits {
+		if slices.Contains(units, unit) {
+			return true
+		}
+	}
+	return false
+}
+
+// quotedList lists values as "a", "b" or "c".
+func quotedList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// spanCount counts a span in the unit, along with its events and links if the unit includes them.
+func spanCount(span ptrace.Span, unit string) int64 {
+	if unit == countUnitSpansEventsLinks {
+		return 1 + int64(span.Events().Len()) + int64(span.Links().Len())
+	}
+	return 1
+}
+
+// scopeSpansCount counts the spans of a scope in the unit.
+func scopeSpansCount(scopeSpans ptrace.ScopeSpans, unit string) int64 {
+	if unit != countUnitSpansEventsLinks {
+		return int64(scopeSpans.Spans().Len())
+	}
+	var count int64
+	for k := 0; k < scopeSpans.Spans().Len(); k++ {
+		count += spanCount(scopeSpans.Spans().At(k), unit)
+	}
+	return count
+}
+
+// scopeMetricsCount counts the metrics of a scope in the unit. Series are the distinct combinations of metric name and
+// data point attributes within the scope.
+func scopeMetricsCount(scopeMetrics pmetric.ScopeMetrics, unit string) int64 {
+	metrics := scopeMetrics.Metrics()
+	switch unit {
+	case countUnitDataPoints, countUnitDataPointsAndBuckets:
+		var count int64
+		for k := 0; k < metrics.Len(); k++ {
+			count += metricCount(metrics.At(k), unit)
+		}
+		return count
+	case countUnitSeries:
+		series := seriesSet{}
+		for k := 0; k < metrics.Len(); k++ {
+			metric := metrics.At(k)
+			forEachDataPointAttributes(metric, func(attributes pcommon.Map) {
+				series.add(metric, attributes)
+			})
+		}
+		return int64(len(series))
+	}
+	return int64(metrics.Len())
+}
+
+// metricCount counts the data points of a metric, along with their histogram buckets if the unit includes them.
+func metricCount(metric pmetric.Metric, unit string) int64 {
+	var count int64
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		count = int64(metric.Gauge().DataPoints().Len())
+	case pmetric.MetricTypeSum:
+		count = int64(metric.Sum().DataPoints().Len())
+	case pmetric.MetricTypeHistogram:
+		dataPoints := metric.Histogram().DataPoints()
+		count = int64(dataPoints.Len())
+		if unit == countUnitDataPointsAndBuckets {
+			for i := 0; i < dataPoints.Len(); i++ {
+				count += histogramBuckets(dataPoints.At(i))
+			}
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dataPoints := metric.ExponentialHistogram().DataPoints()
+		count = int64(dataPoints.Len())
+		if unit == countUnitDataPointsAndBuckets {
+			for i := 0; i < dataPoints.Len(); i++ {
+				count += exponentialHistogramBuckets(dataPoints.At(i))
+			}
+		}
+	case pmetric.MetricTypeSummary:
+		count = int64(metric.Summary().DataPoints().Len())
+	}
+	return count
+}
+
+// histogramBuckets returns the number of buckets of a histogram data point.
+func histogramBuckets(dataPoint pmetric.HistogramDataPoint) int64 {
+	return int64(dataPoint.BucketCounts().Len())
+}
+
+// exponentialHistogramBuckets returns the number of positive and negative buckets of an exponential histogram data
+// point. The zero bucket is a single count that is not stored as a bucket.
+func exponentialHistogramBuckets(dataPoint pmetric.ExponentialHistogramDataPoint) int64 {
+	return int64(dataPoint.Positive().BucketCounts().Len()) + int64(dataPoint.Negative().BucketCounts().Len())
+}
+
+// forEachDataPoint calls f with every data point of a metric, its attributes, timestamp and number of buckets, and the
+// size of its fields, until f returns an error.
+func forEachDataPoint(metric pmetric.Metric, f func(dataPoint any, attributes pcommon.Map, timestamp pcommon.Timestamp, buckets int64, fieldsSize func() int) error) error {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		dataPoints := metric.Gauge().DataPoints()
+		for i := 0; i < dataPoints.Len(); i++ {
+			dataPoint := dataPoints.At(i)
+			if err := f(dataPoint, dataPoint.Attributes(), dataPoint.Timestamp(), 0, func() int { return numberDataPointFieldsSize(dataPoint) }); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeSum:
+		dataPoints := metric.Sum().DataPoints()
+		for i := 0; i < dataPoints.Len(); i++ {
+			dataPoint := dataPoints.At(i)
+			if err := f(dataPoint, dataPoint.Attributes(), dataPoint.Timestamp(), 0, func() int { return numberDataPointFieldsSize(dataPoint) }); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeHistogram:
+		dataPoints := metric.Histogram().DataPoints()
+		for i := 0; i < dataPoints.Len(); i++ {
+			dataPoint := dataPoints.At(i)
+			if err := f(dataPoint, dataPoint.Attributes(), dataPoint.Timestamp(), histogramBuckets(dataPoint), func() int { return histogramDataPointFieldsSize(dataPoint) }); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dataPoints := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dataPoints.Len(); i++ {
+			dataPoint := dataPoints.At(i)
+			if err := f(dataPoint, dataPoint.Attributes(), dataPoint.Timestamp(), exponentialHistogramBuckets(dataPoint), func() int { return exponentialHistogramDataPointFieldsSize(dataPoint) }); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeSummary:
+		dataPoints := metric.Summary().DataPoints()
+		for i := 0; i < dataPoints.Len(); i++ {
+			dataPoint := dataPoints.At(i)
+			if err := f(dataPoint, dataPoint.Attributes(), dataPoint.Timestamp(), 0, func() int { return summaryDataPointFieldsSize(dataPoint) }); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// forEachDataPointAttributes calls f with the attributes of every data point of a metric.
+func forEachDataPointAttributes(metric pmetric.Metric, f func(attributes pcommon.Map)) {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < metric.Gauge().DataPoints().Len(); i++ {
+			f(metric.Gauge().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < metric.Sum().DataPoints().Len(); i++ {
+			f(metric.Sum().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < metric.Histogram().DataPoints().Len(); i++ {
+			f(metric.Histogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < metric.ExponentialHistogram().DataPoints().Len(); i++ {
+			f(metric.ExponentialHistogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < metric.Summary().DataPoints().Len(); i++ {
+			f(metric.Summary().DataPoints().At(i).Attributes())
+		}
+	}
+}
+
+type seriesKey struct {
+	name       string
+	attributes [16]byte
+}
+
+// seriesSet holds the series seen within a scope, identified by metric name and data point attributes. It also holds
+// the metrics seen per output label set, identified by metric name and labels.
+type seriesSet map[seriesKey]struct{}
+
+// add adds the series of a data point, and reports whether it was not seen before.
+func (s seriesSet) add(metric pmetric.Metric, attributes pcommon.Map) bool {
+	key := seriesKey{name: metric.Name(), attributes: pdatautil.MapHash(attributes)}
+	if _, ok := s[key]; ok {
+		return false
+	}
+	s[key] = struct{}{}
+	return true
+}
@@ -0,0 +1,424 @@
+package datavolumeconnector
+
+import (
+	"context"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlresource"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlscope"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+const (
+	metricKindCount = "count"
+	metricKindBytes = "bytes"
+)
+
+// MetricConfig defines a named output metric with its own labels and conditions.
+type MetricConfig struct {
+	// The name of the output metric.
+	Name string `mapstructure:"name"`
+	// What the metric measures, count (the default) for the number of items or bytes for their OTLP size.
+	Kind string `mapstructure:"kind"`
+	// The description of the output metric.
+	Description string `mapstructure:"description"`
+	// The unit of the output metric. Defaults to bytes for bytes metrics.
+	Unit string `mapstructure:"unit"`
+	// Output labels computed by OTTL value expressions, see Config.Dimensions.
+	Dimensions []DimensionConfig `mapstructure:"dimensions"`
+	// Defaults, renames and normalization of the output labels of the metric, see Config.Labels.
+	Labels []LabelConfig `mapstructure:"labels"`
+	// OTTL conditions per signal that select the records to measure, see Config.Conditions.
+	Conditions ConditionsConfig `mapstructure:"conditions"`
+	// The maximum number of label sets of the metric, see Config.MaxSeries.
+	MaxSeries int `mapstructure:"max_series"`
+	// The maximum number of distinct values per label of the metric, see Config.MaxLabelValues.
+	MaxLabelValues map[string]int `mapstructure:"max_label_values"`
+}
+
+// viewConfig translates the metric into the options of the single view it makes up.
+func (m MetricConfig) viewConfig() *Config {
+	cfg := &Config{
+		Dimensions:     m.Dimensions,
+		Labels:         m.Labels,
+		Conditions:     m.Conditions,
+		MaxSeries:      m.MaxSeries,
+		MaxLabelValues: m.MaxLabelValues,
+	}
+	if m.Kind == metricKindBytes {
+		cfg.BytesMetricName = m.Name
+	} else {
+		cfg.CountMetricName = m.Name
+	}
+	return cfg
+}
+
+// view is a set of output metrics that share their labels, their conditions and the granularity they are measured at.
+// The top-level options of the connector make up one view and each entry of metrics another.
+type view struct {
+	config        Config
+	description   string
+	unit          string
+	temporality   pmetric.AggregationTemporality
+	dataType      DataTypeConfig
+	complexValues string
+	errorMode     ottl.ErrorMode
+	logger        *zap.Logger
+	series        *seriesTracker
+	maxExemplars  int
+
+	// constantLabels are the static and identity labels of the connector
+	constantLabels     pcommon.Map
+	resourceDimensions []dimension[ottlresource.TransformContext]
+	scopeDimensions    []dimension[ottlscope.TransformContext]
+	labels             []labelMapping
+
+	// the matchers are only set when the label attribute lists hold patterns
+	resourceMatcher *attributeMatcher
+	scopeMatcher    *attributeMatcher
+	recordMatcher   *attributeMatcher
+	recordKeys      []string
+
+	// only the conditions and record dimensions of the signal the connector was created for are set
+	logConditions       *ottl.ConditionSequence[ottllog.TransformContext]
+	spanConditions      *ottl.ConditionSequence[ottlspan.TransformContext]
+	dataPointConditions *ottl.ConditionSequence[ottldatapoint.TransformContext]
+	logDimensions       []dimension[ottllog.TransformContext]
+	spanDimensions      []dimension[ottlspan.TransformContext]
+	dataPointDimensions []dimension[ottldatapoint.TransformContext]
+
+	// aggregator is only set when a flush interval is configured
+	aggregator *aggregator
+	// buckets is only set when measurements are bucketed by event time
+	buckets *bucketAggregator
+	// limiter is only set when the series of the view are limited
+	limiter *seriesLimiter
+}
+
+func newView(cfg *Config, connectorConfig *Config, errorMode ottl.ErrorMode, set component.TelemetrySettings) (*view, error) {
+	v := &view{
+		config:        *cfg,
+		temporality:   pmetric.AggregationTemporalityDelta,
+		dataType:      connectorConfig.DataType,
+		complexValues: connectorConfig.ComplexValues,
+		errorMode:     errorMode,
+		logger:        set.Logger,
+		series:        newSeriesTracker(connectorConfig.Temporality, connectorConfig.SeriesExpiration, connectorConfig.EventTime.enabled()),
+	}
+	// exemplars are sized records, so only the bytes sum carries them
+	if cfg.BytesMetricName != "" && !cfg.BytesByComponent {
+		v.maxExemplars = connectorConfig.MaxExemplars
+	}
+	if connectorConfig.Temporality == temporalityCumulative {
+		v.temporality = pmetric.AggregationTemporalityCumulative
+	}
+	if connectorConfig.FlushInterval > 0 {
+		v.aggregator = newAggregator()
+	}
+	if connectorConfig.EventTime.enabled() {
+		v.buckets = newBucketAggregator(connectorConfig.EventTime)
+	}
+	v.limiter = newSeriesLimiter(cfg.MaxSeries, cfg.MaxLabelValues, connectorConfig.SeriesExpiration, set.Logger)
+
+	var err error
+	if v.resourceDimensions, err = newResourceDimensions(cfg, set); err != nil {
+		return nil, err
+	}
+	if v.scopeDimensions, err = newScopeDimensions(cfg, set); err != nil {
+		return nil, err
+	}
+	if v.labels, err = newLabelMappings(cfg.Labels); err != nil {
+		return nil, err
+	}
+	v.recordKeys, _ = splitAttributeKeys(cfg.LabelRecordAttributes)
+	if v.resourceMatcher, err = newAttributeMatcher("resource", cfg.LabelResourceAttributes, cfg, set.Logger); err != nil {
+		return nil, err
+	}
+	if v.scopeMatcher, err = newAttributeMatcher("scope", cfg.LabelScopeAttributes, cfg, set.Logger); err != nil {
+		return nil, err
+	}
+	if v.recordMatcher, err = newAttributeMatcher("record", cfg.LabelRecordAttributes, cfg, set.Logger); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// resourceLabels builds the output label set for a resource from the configured resource dimensions.
+func (v *view) resourceLabels(ctx context.Context, resource pcommon.Resource, schemaURLItem schemaURLItem, dataType string) (pcommon.Map, error) {
+	attributes := v.constantLabelSet(dataType)
+	if err := putDimensions(ctx, v.resourceDimensions, ottlresource.NewTransformContext(resource, schemaURLItem), attributes, v.errorMode, v.logger); err != nil {
+		return attributes, err
+	}
+	if v.resourceMatcher != nil {
+		v.resourceMatcher.put(attributes, resource.Attributes())
+	}
+	return attributes, nil
+}
+
+// constantLabelSet returns the labels that every series of the view carries for the given data type, the data type label
+// and the static and identity labels, mapped like the label set of any measurement.
+func (v *view) constantLabelSet(dataType string) pcommon.Map {
+	attributes := pcommon.NewMap()
+	if key, value := v.dataType.label(dataType); key != "" {
+		attributes.PutStr(key, value)
+	}
+	v.constantLabels.Range(func(key string, value pcommon.Value) bool {
+		value.CopyTo(attributes.PutEmpty(key))
+		return true
+	})
+	return attributes
+}
+
+// scopeLabels extends the label set of a resource with the configured scope dimensions of one of its scopes.
+func (v *view) scopeLabels(ctx context.Context, resourceLabels pcommon.Map, scope pcommon.InstrumentationScope, resource pcommon.Resource, schemaURLItem schemaURLItem) (pcommon.Map, error) {
+	if !v.measureScopes() {
+		return resourceLabels, nil
+	}
+	attributes := pcommon.NewMap()
+	resourceLabels.CopyTo(attributes)
+	if err := putDimensions(ctx, v.scopeDimensions, ottlscope.NewTransformContext(scope, resource, schemaURLItem), attributes, v.errorMode, v.logger); err != nil {
+		return attributes, err
+	}
+	if v.scopeMatcher != nil {
+		v.scopeMatcher.put(attributes, scope.Attributes())
+	}
+	return attributes, nil
+}
+
+// recordLabels extends the label set of a scope with the configured attributes of one of its records.
+func (v *view) recordLabels(scopeLabels pcommon.Map, recordAttributes pcommon.Map) pcommon.Map {
+	attributes := pcommon.NewMap()
+	scopeLabels.CopyTo(attributes)
+	for _, key := range v.recordKeys {
+		if value, ok := lookupAttribute(recordAttributes, key); ok {
+			value.CopyTo(attributes.PutEmpty(key))
+		}
+	}
+	if v.recordMatcher != nil {
+		v.recordMatcher.put(attributes, recordAttributes)
+	}
+	return attributes
+}
+
+// mapLabels serializes complex values and applies the configured label mappings to the final label set of a
+// measurement.
+func (v *view) mapLabels(attributes pcommon.Map) {
+	serializeComplexValues(attributes, v.complexValues)
+	mapLabels(v.labels, attributes)
+}
+
+// measureScopes reports whether scopes need to be measured one by one.
+func (v *view) measureScopes() bool {
+	return len(v.scopeDimensions) > 0 || v.scopeMatcher != nil
+}
+
+// measureRecords reports whether log records, spans and data points need to be measured one by one, because their
+// labels or the conditions selecting them depend on the record. The counts and bytes of the view then cover the
+// records alone.
+func (v *view) measureRecords() bool {
+	return len(v.config.LabelRecordAttributes) > 0 ||
+		v.logConditions != nil || v.spanConditions != nil || v.dataPointConditions != nil ||
+		len(v.logDimensions) > 0 || len(v.spanDimensions) > 0 || len(v.dataPointDimensions) > 0
+}
+
+// sampleRecords reports whether the view samples the records of resources or scopes for its size and lag histograms,
+// timestamp anomalies or exemplars, while it measures their counts and bytes as a whole.
+func (v *view) sampleRecords() bool {
+	return !v.measureRecords() && (v.config.SizeHistogramMetricName != "" || v.config.LagHistogramMetricName != "" ||
+		v.config.TimestampAnomalyMetricName != "" || v.maxExemplars > 0)
+}
+
+// measure adds the size of a resource, scope or record to the bytes of its measurement, if the view has any use for
+// them.
+func (v *view) measure(volume *dataVolume, s *sizing) {
+	if v.config.BytesMetricName == "" {
+		return
+	}
+	volume.bytes += int64(s.size())
+	if v.config.BytesByComponent {
+		volume.components.add(s.components())
+	}
+}
+
+// sample adds a single record to the size and lag histograms, timestamp anomalies and exemplars of its measurement, if
+// the view has any use for them.
+func (v *view) sample(volume *dataVolume, record *recordSample) {
+	if v.config.SizeHistogramMetricName != "" {
+		if volume.sizes == nil {
+			volume.sizes = newSizeHistogram(v.config.SizeHistogram, defaultSizeBuckets)
+		}
+		volume.sizes.record(record.sizing.size())
+	}
+	v.sampleTimestamp(volume, record.timestamp)
+	if v.maxExemplars > 0 && !record.traceID.IsEmpty() {
+		volume.exemplars.max = v.maxExemplars
+		volume.exemplars.add(exemplar{traceID: record.traceID, spanID: record.spanID, size: record.sizing.size(), timestamp: record.time})
+	}
+}
+
+// sampleTimestamp adds the lag and anomaly of a record to its measurement, if the view has any use for them.
+func (v *view) sampleTimestamp(volume *dataVolume, timestamp recordTimestamp) {
+	if timestamp.anomaly != anomalyNone {
+		if v.config.TimestampAnomalyMetricName != "" {
+			volume.anomalies[timestamp.anomaly]++
+		}
+		if timestamp.anomaly != anomalyOutOfOrder {
+			return
+		}
+	}
+	if v.config.LagHistogramMetricName != "" {
+		if volume.lags == nil {
+			volume.lags = newSizeHistogram(v.config.LagHistogram, defaultLagBuckets)
+		}
+		volume.lags.record(timestamp.lag)
+	}
+}
+
+// appendMetrics adds the output metrics of the measurements to output, one series per label set.
+func (v *view) appendMetrics(output *metricsOutput, volumes []dataVolume, windowStart, timestamp pcommon.Timestamp) {
+	v.series.sweep(timestamp.AsTime())
+	for _, volume := range volumes {
+		start, totals := v.series.record(volume, windowStart, timestamp)
+		scope := output.scope(volume.attributes)
+
+		if v.config.CountMetricName != "" {
+			sum := v.appendSum(scope, v.config.CountMetricName, "")
+			addSumDataPoint(sum, scope.labels, start, timestamp, totals.count)
+		}
+		if v.config.ProfileCountMetricName != "" {
+			sum := v.appendSum(scope, v.config.ProfileCountMetricName, "")
+			addSumDataPoint(sum, scope.labels, start, timestamp, totals.profiles)
+		}
+		if v.config.BytesMetricName != "" {
+			sum := v.appendSum(scope, v.config.BytesMetricName, "bytes")
+			if v.config.BytesByComponent {
+				addComponentDataPoints(sum, scope.labels, start, timestamp, totals.components)
+			} else {
+				totals.exemplars.copyTo(addSumDataPoint(sum, scope.labels, start, timestamp, totals.bytes).Exemplars())
+			}
+		}
+		if v.config.SizeHistogramMetricName != "" && totals.sizes != nil {
+			metric := v.appendMetric(scope, v.config.SizeHistogramMetricName, "bytes")
+			totals.sizes.copyTo(metric, scope.labels, v.temporality, start, timestamp)
+		}
+		if v.config.LagHistogramMetricName != "" && totals.lags != nil {
+			metric := v.appendMetric(scope, v.config.LagHistogramMetricName, "ms")
+			totals.lags.copyTo(metric, scope.labels, v.temporality, start, timestamp)
+		}
+		if v.config.TimestampAnomalyMetricName != "" {
+			sum := v.appendSum(scope, v.config.TimestampAnomalyMetricName, "")
+			addAnomalyDataPoints(sum, scope.labels, start, timestamp, totals.anomalies)
+		}
+		if volume.overflow {
+			v.appendRejectedSeries(scope, timestamp)
+		}
+	}
+}
+
+// appendRejectedSeries reports how many distinct label sets were folded into the overflow series, per metric of the
+// view.
+func (v *view) appendRejectedSeries(scope outputScope, timestamp pcommon.Timestamp) {
+	metric, added := scope.metric(rejectedSeriesMetricName)
+	if added {
+		metric.SetDescription("Distinct label sets folded into the overflow series")
+		metric.SetEmptyGauge()
+	}
+	rejected := v.limiter.rejectedSeries()
+	for _, name := range []string{v.config.CountMetricName, v.config.ProfileCountMetricName, v.config.BytesMetricName, v.config.SizeHistogramMetricName,
+		v.config.LagHistogramMetricName, v.config.TimestampAnomalyMetricName} {
+		if name == "" {
+			continue
+		}
+		dataPoint := metric.Gauge().DataPoints().AppendEmpty()
+		scope.labels.CopyTo(dataPoint.Attributes())
+		dataPoint.Attributes().PutStr(rejectedMetricAttributeKey, name)
+		dataPoint.SetTimestamp(timestamp)
+		dataPoint.SetIntValue(rejected)
+	}
+}
+
+// appendMetric returns the output metric of the given name in the scope, adding it if it is not there yet.
+func (v *view) appendMetric(scope outputScope, name string, defaultUnit string) pmetric.Metric {
+	metric, added := scope.metric(name)
+	if !added {
+		return metric
+	}
+	metric.SetDescription(v.description)
+	if v.unit != "" {
+		metric.SetUnit(v.unit)
+	} else if defaultUnit != "" {
+		metric.SetUnit(defaultUnit)
+	}
+	return metric
+}
+
+func (v *view) appendSum(scope outputScope, name string, defaultUnit string) pmetric.Sum {
+	metric := v.appendMetric(scope, name, defaultUnit)
+	if metric.Type() != pmetric.MetricTypeSum {
+		sum := metric.SetEmptySum()
+		sum.SetIsMonotonic(true)
+		sum.SetAggregationTemporality(v.temporality)
+	}
+	return metric.Sum()
+}
+
+func addSumDataPoint(sum pmetric.Sum, labels pcommon.Map, start, timestamp pcommon.Timestamp, value int64) pmetric.NumberDataPoint {
+	dataPoint := sum.DataPoints().AppendEmpty()
+	labels.CopyTo(dataPoint.Attributes())
+	dataPoint.SetStartTimestamp(start)
+	dataPoint.SetTimestamp(timestamp)
+	dataPoint.SetIntValue(value)
+	return dataPoint
+}
+
+// addComponentDataPoints adds a data point per component to a bytes sum. Components without bytes are left out, except
+// for the overhead that every measurement has.
+func addComponentDataPoints(sum pmetric.Sum, labels pcommon.Map, start, timestamp pcommon.Timestamp, components byteComponents) {
+	for component, value := range components {
+		if value == 0 && component != componentOverhead {
+			continue
+		}
+		dataPoint := sum.DataPoints().AppendEmpty()
+		labels.CopyTo(dataPoint.Attributes())
+		dataPoint.Attributes().PutStr(componentAttributeKey, componentNames[component])
+		dataPoint.SetStartTimestamp(start)
+		dataPoint.SetTimestamp(timestamp)
+		dataPoint.SetIntValue(value)
+	}
+}
+
+// sizing measures a resource, scope or record at most once, however many views need its size.
+type sizing struct {
+	measureSize       func() int
+	measureComponents func() byteComponents
+
+	measured        bool
+	measuredSize    int
+	split           bool
+	splitComponents byteComponents
+}
+
+func newSizing(measureSize func() int, measureComponents func() byteComponents) *sizing {
+	return &sizing{measureSize: measureSize, measureComponents: measureComponents}
+}
+
+func (s *sizing) size() int {
+	if !s.measured {
+		s.measuredSize = s.measureSize()
+		s.measured = true
+	}
+	return s.measuredSize
+}
+
+func (s *sizing) components() byteComponents {
+	if !s.split {
+		s.splitComponents = s.measureComponents().withOverhead(s.size())
+		s.split = true
+	}
+	return s.splitComponents
+}